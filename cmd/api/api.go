@@ -13,26 +13,33 @@ import (
 	"github.com/skiba-mateusz/communiverse/internal/auth"
 	"github.com/skiba-mateusz/communiverse/internal/mailer"
 	"github.com/skiba-mateusz/communiverse/internal/store"
+	"github.com/skiba-mateusz/communiverse/internal/throttle"
 	"go.uber.org/zap"
 )
 
 type application struct {
-	config        config
-	logger        *zap.SugaredLogger
-	store         store.Storage
-	mailer        mailer.Client
-	authenticator auth.Authenticator
-	uploader      uploader.Client
+	config            config
+	logger            *zap.SugaredLogger
+	store             store.Storage
+	mailer            mailer.Client
+	authenticator     auth.Authenticator
+	uploader          uploader.Client
+	identityProviders map[string]auth.IdentityProvider
+	limiters          limiters
 }
 
 type config struct {
 	addr        string
 	env         string
+	apiURL      string
 	frontendURL string
 	db          dbConfig
 	mail        mailConfig
 	auth        authConfig
+	oidc        oidcConfig
 	upload      uploadConfig
+	throttle    throttleConfig
+	jobs        jobsConfig
 }
 
 type dbConfig struct {
@@ -63,9 +70,36 @@ type basicConfig struct {
 }
 
 type tokenConfig struct {
-	secret string
-	exp    time.Duration
-	iss    string
+	secret       string
+	keys         string
+	activeKID    string
+	exp          time.Duration
+	refreshExp   time.Duration
+	mfaExp       time.Duration
+	magicLinkExp time.Duration
+	iss          string
+}
+
+type oidcConfig struct {
+	provider     string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	stateExp     time.Duration
+}
+
+type throttleConfig struct {
+	backend string
+	account throttle.Policy
+	ip      throttle.Policy
+	email   throttle.Policy
+}
+
+type jobsConfig struct {
+	interval           time.Duration
+	publishInterval    time.Duration
+	inactiveRetention  time.Duration
+	tombstoneRetention time.Duration
 }
 
 type uploadConfig struct {
@@ -90,11 +124,14 @@ func (app *application) mount() http.Handler {
 	}))
 	r.Use(middleware.Timeout(60 * time.Second))
 
+	r.Get("/.well-known/jwks.json", app.jwksHandler)
+
 	r.Route("/v1", func(r chi.Router) {
 		r.With(app.basicAuthMiddleware()).Get("/health", app.healthHandler)
 
 		r.Mount("/communities", app.communityRoutes())
 		r.Mount("/posts", app.postRoutes())
+		r.Mount("/modqueue", app.modQueueRoutes())
 		r.Mount("/users", app.userRoutes())
 		r.Mount("/auth", app.authRoutes())
 	})
@@ -120,7 +157,69 @@ func (app *application) communityRoutes() http.Handler {
 		r.Post("/join", app.joinCommunityHandler)
 		r.Delete("/leave", app.leaveCommunityHandler)
 
-		r.Mount("/posts", app.communityPostRoutes())
+		r.With(app.requireCommunityAccess).Get("/rules", app.getRulesHandler)
+		r.Put("/rules", app.authorizeWithOwnership("admin", "community", app.updateRulesHandler))
+
+		r.Route("/members", func(r chi.Router) {
+			r.With(app.requireCommunityAccess).Get("/", app.getCommunityMembersHandler)
+			r.Patch("/{username}", app.authorizeWithOwnership("admin", "community", app.updateMemberRoleHandler))
+			r.Delete("/{username}", app.authorizeWithOwnership("moderator", "community", app.removeMemberHandler))
+			r.Put("/{username}/flair", app.authorizeWithOwnership("admin", "community", app.setMemberFlairHandler))
+		})
+
+		r.Route("/flairs", func(r chi.Router) {
+			r.With(app.requireCommunityAccess).Get("/", app.getFlairsHandler)
+			r.Post("/", app.authorizeWithOwnership("admin", "community", app.createFlairHandler))
+			r.Patch("/{id}", app.authorizeWithOwnership("admin", "community", app.updateFlairHandler))
+			r.Delete("/{id}", app.authorizeWithOwnership("admin", "community", app.deleteFlairHandler))
+		})
+
+		r.Put("/flair", app.setUserFlairHandler)
+
+		r.Route("/join-requests", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("admin", "community", app.getJoinRequestsHandler))
+			r.Post("/{id}/approve", app.authorizeWithOwnership("admin", "community", app.approveJoinRequestHandler))
+			r.Post("/{id}/deny", app.authorizeWithOwnership("admin", "community", app.denyJoinRequestHandler))
+		})
+
+		r.Route("/invites", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("admin", "community", app.getInvitesHandler))
+			r.Post("/", app.authorizeWithOwnership("admin", "community", app.createInviteHandler))
+			r.Delete("/{id}", app.authorizeWithOwnership("admin", "community", app.deleteInviteHandler))
+		})
+
+		r.Route("/transfer", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("admin", "community", app.getTransferHandler))
+			r.Post("/", app.authorizeWithOwnership("admin", "community", app.nominateTransferHandler))
+			r.Delete("/", app.authorizeWithOwnership("admin", "community", app.cancelTransferHandler))
+			r.Post("/accept", app.authorizeWithOwnership("admin", "community", app.acceptTransferHandler))
+			r.Post("/force", app.authorizeWithRole("staff", app.forceTransferHandler))
+		})
+
+		r.Route("/bans", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("moderator", "community", app.getCommunityBansHandler))
+			r.Post("/", app.authorizeWithOwnership("moderator", "community", app.createBanHandler))
+			r.Delete("/{id}", app.authorizeWithOwnership("moderator", "community", app.deleteBanHandler))
+		})
+
+		r.Route("/automod", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("admin", "community", app.getAutoModRulesHandler))
+			r.Put("/", app.authorizeWithOwnership("admin", "community", app.updateAutoModRulesHandler))
+			r.Post("/dry-run", app.authorizeWithOwnership("admin", "community", app.dryRunAutoModHandler))
+		})
+
+		r.With(app.requireCommunityAccess).Get("/modlog", app.authorizeModLog(app.getModLogHandler))
+
+		r.Route("/modqueue", func(r chi.Router) {
+			r.Get("/", app.authorizeWithOwnership("moderator", "community", app.getModQueueHandler))
+			r.Route("/{targetType:post|comment}/{targetID:[0-9]+}", func(r chi.Router) {
+				r.Post("/approve", app.authorizeWithOwnership("moderator", "community", app.approveQueueItemHandler))
+				r.Post("/remove", app.authorizeWithOwnership("moderator", "community", app.removeQueueItemHandler))
+				r.Post("/remove-and-ban", app.authorizeWithOwnership("moderator", "community", app.removeAndBanQueueItemHandler))
+			})
+		})
+
+		r.With(app.requireCommunityAccess).Mount("/posts", app.communityPostRoutes())
 	})
 
 	return r
@@ -130,15 +229,28 @@ func (app *application) communityPostRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", app.getCommunityPostsHandler)
-	r.Post("/", app.authorizeWithOwnership("member", "community", app.createPostHandler))
+	r.Post("/", app.authorizeWithOwnership("member", "community", app.requireNotRestricted(app.createPostHandler)))
 
 	r.Route("/{postSlug}", func(r chi.Router) {
 		r.Use(app.postContextMiddleware)
 
 		r.Get("/", app.getPostHandler)
-		r.Delete("/", app.authorizeWithOwnership("admin", "post", app.deletePostHandler))
+		r.Delete("/", app.authorizeWithOwnership("moderator", "post", app.deletePostHandler))
 		r.Patch("/", app.authorizeWithOwnership("admin", "post", app.updatePostHandler))
-		r.Put("/vote", app.votePostHandler)
+		r.Get("/revisions", app.authorizeWithOwnership("moderator", "post", app.getPostRevisionsHandler))
+		r.Route("/media", func(r chi.Router) {
+			r.Get("/", app.getPostMediaHandler)
+			r.Post("/", app.authorizeWithOwnership("admin", "post", app.requireNotRestricted(app.uploadPostMediaHandler)))
+			r.Put("/order", app.authorizeWithOwnership("admin", "post", app.requireNotRestricted(app.reorderPostMediaHandler)))
+			r.Patch("/{mediaID}", app.authorizeWithOwnership("admin", "post", app.requireNotRestricted(app.updatePostMediaHandler)))
+			r.Delete("/{mediaID}", app.authorizeWithOwnership("admin", "post", app.requireNotRestricted(app.deletePostMediaHandler)))
+		})
+		r.Put("/vote", app.requireNotRestricted(app.requireUnlocked(app.votePostHandler)))
+		r.Put("/pin", app.authorizeWithOwnership("admin", "community", app.pinPostHandler))
+		r.Delete("/pin", app.authorizeWithOwnership("admin", "community", app.unpinPostHandler))
+		r.Put("/lock", app.authorizeWithOwnership("admin", "community", app.lockPostHandler))
+		r.Delete("/lock", app.authorizeWithOwnership("admin", "community", app.unlockPostHandler))
+		r.Post("/report", app.reportPostHandler)
 
 		r.Mount("/comments", app.postCommentRoutes())
 	})
@@ -156,18 +268,36 @@ func (app *application) postRoutes() http.Handler {
 	return r
 }
 
+func (app *application) modQueueRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Use(app.tokenAuthMiddleware)
+
+	r.Get("/", app.authorizeWithRole("staff", app.getModQueueHandler))
+	r.Route("/{targetType:post|comment}/{targetID:[0-9]+}", func(r chi.Router) {
+		r.Post("/approve", app.authorizeWithRole("staff", app.approveQueueItemHandler))
+		r.Post("/remove", app.authorizeWithRole("staff", app.removeQueueItemHandler))
+		r.Post("/remove-and-ban", app.authorizeWithRole("staff", app.removeAndBanQueueItemHandler))
+		r.Post("/restore", app.authorizeWithRole("staff", app.restoreQueueItemHandler))
+	})
+
+	return r
+}
+
 func (app *application) postCommentRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", app.getCommentsHandler)
-	r.Post("/", app.authorizeWithOwnership("member", "post", app.createCommentHandler))
+	r.Post("/", app.authorizeWithOwnership("member", "post", app.requireNotRestricted(app.requireUnlocked(app.createCommentHandler))))
 
 	r.Route("/{id}", func(r chi.Router) {
 		r.Use(app.commentContextMiddleware)
 
 		r.Patch("/", app.authorizeWithOwnership("admin", "comment", app.updateCommentHandler))
-		r.Delete("/", app.authorizeWithOwnership("admin", "comment", app.deleteCommentHandler))
-		r.Put("/vote", app.voteCommentHandler)
+		r.Get("/revisions", app.authorizeWithOwnership("moderator", "comment", app.getCommentRevisionsHandler))
+		r.Delete("/", app.authorizeWithOwnership("moderator", "comment", app.deleteCommentHandler))
+		r.Put("/vote", app.requireNotRestricted(app.requireUnlocked(app.voteCommentHandler)))
+		r.Post("/report", app.reportCommentHandler)
 	})
 
 	return r
@@ -177,6 +307,7 @@ func (app *application) userRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Put("/activate/{token}", app.activateUserHandler)
+	r.Put("/email/{token}", app.confirmEmailChangeHandler)
 
 	r.Group(func(r chi.Router) {
 		r.Use(app.tokenAuthMiddleware)
@@ -184,13 +315,34 @@ func (app *application) userRoutes() http.Handler {
 		r.Route("/me", func(r chi.Router)  {
 			r.Get("/", app.getCurrentUserHandler)
 			r.Get("/feed", app.getCurrentUserFeedHandler)
+			r.Get("/drafts", app.getCurrentUserDraftsHandler)
 			r.Get("/communities", app.getCurrentUserCommunitiesHandler)
 			r.Delete("/", app.deleteCurrentUserHandler)
 			r.Patch("/", app.updateCurrentUserHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.requireSessionMiddleware)
+
+				r.Get("/sessions", app.getCurrentUserSessionsHandler)
+				r.Delete("/sessions", app.revokeOtherSessionsHandler)
+				r.Delete("/sessions/{id}", app.revokeCurrentUserSessionHandler)
+
+				r.Put("/password", app.changePasswordHandler)
+				r.Post("/email", app.changeEmailHandler)
+
+				r.Post("/mfa/totp", app.enrollTOTPHandler)
+				r.Post("/mfa/totp/confirm", app.confirmTOTPHandler)
+				r.Delete("/mfa/totp", app.disableTOTPHandler)
+
+				r.Get("/tokens", app.getTokensHandler)
+				r.Post("/tokens", app.createTokenHandler)
+				r.Delete("/tokens/{id}", app.deleteTokenHandler)
+			})
 		})
 
 		r.Route("/{username}", func(r chi.Router) {
 			r.Get("/", app.getUserHandler)
+			r.Delete("/sessions", app.authorizeWithRole("staff", app.revokeUserSessionsHandler))
 		})
 	})
 
@@ -201,9 +353,18 @@ func (app *application) authRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Post("/register", app.registerUserHandler)
+	r.Post("/resend-activation", app.resendActivationHandler)
 	r.Post("/login", app.loginUserHandler)
+	r.Post("/mfa", app.verifyMFAHandler)
+	r.Post("/refresh", app.refreshTokenHandler)
+	r.With(app.tokenAuthMiddleware, app.requireSessionMiddleware).Post("/logout", app.logoutUserHandler)
 	r.Post("/forgot-password", app.forgotPasswordHandler)
 	r.Put("/reset-password/{token}", app.resetPasswordHandler)
+	r.Post("/magic-link", app.requestMagicLinkHandler)
+	r.Put("/magic-link/{token}", app.magicLinkLoginHandler)
+
+	r.Get("/oidc/{provider}/start", app.oidcStartHandler)
+	r.Get("/oidc/{provider}/callback", app.oidcCallbackHandler)
 
 	return r
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,6 +16,11 @@ import (
 	"github.com/skiba-mateusz/communiverse/internal/store"
 )
 
+const (
+	accessTokenType = "access"
+	mfaTokenType    = "mfa"
+)
+
 type RegisterUserPayload struct {
 	Name     string `json:"name" validate:"required,min=3,max=100"`
 	Username string `json:"username" validate:"required,min=3,max=100"`
@@ -65,35 +71,81 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	activationURL := fmt.Sprintf("%s/auth/confirm/%s", app.config.frontendURL, plainToken)
+	if err := app.sendInvitationEmail(user, plainToken); err != nil {
+		app.logger.Errorw("error sending invitation email", "error", err)
 
-	vars := struct {
-		Username      string
-		ActivationURL string
-	}{
-		Username:      user.Username,
-		ActivationURL: activationURL,
+		if err := app.store.Users.Delete(ctx, user.ID); err != nil {
+			app.logger.Errorw("error deleting user", "error", err)
+		}
+
+		app.internalServerError(w, r, err)
+		return
 	}
 
-	isProd := app.config.env == "production"
+	if err := jsonResponse(w, http.StatusCreated, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
 
-	statusCode, err := app.mailer.Send(mailer.InviteUserTemplate, user.Username, user.Email, vars, !isProd)
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+func (app *application) resendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResendActivationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	retryAfter, err := app.throttleEmail(ctx, "resend-activation", payload.Email, getClientIP(r))
 	if err != nil {
-		app.logger.Errorw("error sending invitation email", "error", err)
+		app.internalServerError(w, r, err)
+		return
+	}
 
-		if err := app.store.Users.Delete(ctx, user.ID); err != nil {
-			app.logger.Errorw("error deleting user", "error", err)
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
+
+	user, err := app.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			app.internalServerError(w, r, err)
 		}
+		return
+	}
 
-		app.internalServerError(w, r, err)
+	if user.IsActive {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	app.logger.Infow("invitation email sent", "status code", statusCode)
+	plainToken, hashToken := generateTokenAndHash()
 
-	if err := jsonResponse(w, http.StatusCreated, user); err != nil {
+	if err = app.store.Users.ReplaceInvitation(ctx, hashToken, app.config.mail.exp, user.ID); err != nil {
 		app.internalServerError(w, r, err)
+		return
 	}
+
+	if err = app.sendInvitationEmail(user, plainToken); err != nil {
+		app.logger.Errorw("error sending invitation email", "error", err)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type LoginUserPayload struct {
@@ -114,11 +166,25 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	ctx := r.Context()
+	ip := getClientIP(r)
+	accountKey := accountThrottleKey(payload.Email)
+
+	retryAfter, err := app.loginRetryAfter(ctx, accountKey, ip)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
 
 	user, err := app.store.Users.GetByEmail(ctx, payload.Email)
 	if err != nil {
 		switch err {
 		case store.ErrNotFound:
+			app.loginFailed(ctx, accountKey, ip, nil)
 			app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid email or password"))
 		default:
 			app.internalServerError(w, r, err)
@@ -126,34 +192,330 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !user.Password.Matches(payload.Password) {
+		app.loginFailed(ctx, accountKey, ip, user)
+		app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid email or password"))
+		return
+	}
+
+	app.loginSucceeded(ctx, accountKey)
+
 	if !user.IsActive {
 		app.specificUnauthorizedResponse(w, r, fmt.Errorf("account not activated, check your email address"))
 		return
 	}
 
-	if !user.Password.Matches(payload.Password) {
-		app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid email or password"))
+	response, err := app.loginResponse(r, user)
+	if err != nil {
+		app.internalServerError(w, r, err)
 		return
 	}
 
-	token, err := app.authenticator.GenerateToken(jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(app.config.auth.token.exp).Unix(),
-		"iat": time.Now().Unix(),
-		"nbf": time.Now().Unix(),
-		"iss": app.config.auth.token.iss,
-		"aud": app.config.auth.token.iss,
-	})
+	if err := jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type MagicLinkPayload struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+func (app *application) requestMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var payload MagicLinkPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	retryAfter, err := app.throttleEmail(ctx, "magic-link", payload.Email, getClientIP(r))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
+
+	user, err := app.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if !user.IsActive {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	plainToken, hashToken := generateTokenAndHash()
+
+	if err = app.store.Users.CreateMagicLink(ctx, hashToken, app.config.auth.token.magicLinkExp, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	vars := struct {
+		Username     string
+		LoginURL     string
+		ExpiresInMin int
+	}{
+		Username:     user.Username,
+		LoginURL:     fmt.Sprintf("%s/auth/magic-link/%s", app.config.frontendURL, plainToken),
+		ExpiresInMin: int(app.config.auth.token.magicLinkExp.Minutes()),
+	}
+
+	isProd := app.config.env == "production"
+
+	statusCode, err := app.mailer.Send(mailer.MagicLinkTemplate, user.Username, user.Email, vars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending magic link email", "error", err)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("magic link email sent", "status code", statusCode)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) magicLinkLoginHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	ctx := r.Context()
+
+	userID, err := app.store.Users.ConsumeMagicLink(ctx, token)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid or expired login link"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	response, err := app.loginResponse(r, user)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
+
+type VerifyMFAPayload struct {
+	MFAToken     string `json:"mfaToken" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code,omitempty,max=32"`
+}
+
+func (app *application) verifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var payload VerifyMFAPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	jwtToken, err := app.authenticator.ValidateToken(payload.MFAToken)
+	if err != nil {
+		app.unauthorizedResponse(w, r, err)
+		return
+	}
+
+	claims, _ := jwtToken.Claims.(jwt.MapClaims)
+
+	if claims["typ"] != mfaTokenType {
+		app.unauthorizedResponse(w, r, fmt.Errorf("invalid token type"))
+		return
+	}
+
+	userID, err := strconv.ParseInt(fmt.Sprintf("%.f", claims["sub"]), 10, 64)
+	if err != nil {
+		app.unauthorizedResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	ip := getClientIP(r)
+	accountKey := mfaThrottleKey(userID)
+
+	retryAfter, err := app.loginRetryAfter(ctx, accountKey, ip)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
+
+	user, err := app.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.Code != "" {
+		valid := false
+		if user.MFAEnabled {
+			valid, err = app.useTOTPCode(ctx, user, payload.Code)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		if !valid {
+			app.loginFailed(ctx, accountKey, ip, user)
+			app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid authentication code"))
+			return
+		}
+	} else {
+		if err = app.store.Users.UseRecoveryCode(ctx, user.ID, payload.RecoveryCode); err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.loginFailed(ctx, accountKey, ip, user)
+				app.specificUnauthorizedResponse(w, r, fmt.Errorf("invalid recovery code"))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	app.loginSucceeded(ctx, accountKey)
+
+	response, err := app.createSession(r, user.ID)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := jsonResponse(w, http.StatusOK, token); err != nil {
+	if err := jsonResponse(w, http.StatusOK, response); err != nil {
 		app.internalServerError(w, r, err)
 	}
 }
 
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refreshToken" validate:"required,max=100"`
+}
+
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	plainToken, hashToken := generateTokenAndHash()
+
+	session, err := app.store.Sessions.Rotate(ctx, payload.RefreshToken, hashToken, app.config.auth.token.refreshExp)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if _, err = app.store.Users.GetByID(ctx, session.UserID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	accessToken, err := app.generateAccessToken(session.UserID, session.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: plainToken,
+	}
+
+	if err := jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) logoutUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	session := getSessionFromContext(r)
+
+	if err := app.store.Sessions.Revoke(r.Context(), session.ID, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 type ForgotPasswordPayload struct {
 	Email string `json:"email" validate:"required,email,max=100"`
 }
@@ -172,6 +534,17 @@ func (app *application) forgotPasswordHandler(w http.ResponseWriter, r *http.Req
 
 	ctx := r.Context()
 
+	retryAfter, err := app.throttleEmail(ctx, "forgot-password", payload.Email, getClientIP(r))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
+
 	user, err := app.store.Users.GetByEmail(ctx, payload.Email)
 	if err != nil {
 		app.logger.Errorw("error finding user for password reset", "error", err)
@@ -248,6 +621,108 @@ func (app *application) resetPasswordHandler(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (app *application) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := writeJSON(w, http.StatusOK, app.authenticator.JWKS()); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) sendInvitationEmail(user *store.UserDetails, plainToken string) error {
+	activationURL := fmt.Sprintf("%s/auth/confirm/%s", app.config.frontendURL, plainToken)
+
+	vars := struct {
+		Username      string
+		ActivationURL string
+	}{
+		Username:      user.Username,
+		ActivationURL: activationURL,
+	}
+
+	isProd := app.config.env == "production"
+
+	statusCode, err := app.mailer.Send(mailer.InviteUserTemplate, user.Username, user.Email, vars, !isProd)
+	if err != nil {
+		return err
+	}
+
+	app.logger.Infow("invitation email sent", "status code", statusCode)
+
+	return nil
+}
+
+// loginResponse finishes a first factor login, handing out an MFA challenge
+// instead of a session when the user has two-factor authentication enabled.
+func (app *application) loginResponse(r *http.Request, user *store.UserDetails) (any, error) {
+	if user.MFAEnabled {
+		mfaToken, err := app.generateMFAToken(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return app.createSession(r, user.ID)
+}
+
+func (app *application) createSession(r *http.Request, userID int64) (TokenResponse, error) {
+	plainToken, hashToken := generateTokenAndHash()
+
+	session := &store.Session{
+		UserID:    userID,
+		UserAgent: r.UserAgent(),
+		IP:        getClientIP(r),
+	}
+
+	if err := app.store.Sessions.Create(r.Context(), session, hashToken, app.config.auth.token.refreshExp); err != nil {
+		return TokenResponse{}, err
+	}
+
+	accessToken, err := app.generateAccessToken(userID, session.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: plainToken,
+	}, nil
+}
+
+func (app *application) generateMFAToken(userID int64) (string, error) {
+	now := time.Now()
+
+	return app.authenticator.GenerateToken(jwt.MapClaims{
+		"sub": userID,
+		"typ": mfaTokenType,
+		"exp": now.Add(app.config.auth.token.mfaExp).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	})
+}
+
+func (app *application) generateAccessToken(userID, sessionID int64) (string, error) {
+	now := time.Now()
+
+	return app.authenticator.GenerateToken(jwt.MapClaims{
+		"sub": userID,
+		"sid": sessionID,
+		"typ": accessTokenType,
+		"exp": now.Add(app.config.auth.token.exp).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	})
+}
+
 func generateTokenAndHash() (string, string) {
 	plainToken := uuid.New().String()
 	hash := sha256.Sum256([]byte(plainToken))
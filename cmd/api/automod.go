@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/automod"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+func (app *application) getAutoModRulesHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	rules, err := app.store.AutoMod.GetRules(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, rules); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type AutoModRulesPayload struct {
+	Rules json.RawMessage `json:"rules" validate:"required"`
+}
+
+func (app *application) updateAutoModRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var payload AutoModRulesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ruleset, err := automod.Parse(payload.Rules)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	rules, err := json.Marshal(ruleset.Rules())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionUpdateAutoMod,
+		TargetType:  "community",
+		TargetID:    &community.ID,
+		TargetLabel: community.Name,
+	})
+
+	if err = app.store.AutoMod.SaveRules(r.Context(), community.ID, user.ID, rules, entry); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, ruleset.Rules()); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DryRunPayload struct {
+	Rules json.RawMessage `json:"rules"`
+	Limit int             `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type DryRunMatch struct {
+	PostID int64          `json:"postID"`
+	Title  string         `json:"title"`
+	Slug   string         `json:"slug"`
+	Rules  []automod.Rule `json:"rules"`
+}
+
+type DryRunResponse struct {
+	Evaluated int           `json:"evaluated"`
+	Matches   []DryRunMatch `json:"matches"`
+}
+
+// dryRunAutoModHandler evaluates the given rules, or the saved ones when none
+// are given, against recent posts without applying any actions.
+func (app *application) dryRunAutoModHandler(w http.ResponseWriter, r *http.Request) {
+	var payload DryRunPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Limit == 0 {
+		payload.Limit = 25
+	}
+
+	community := getCommunityFromContext(r)
+	ctx := r.Context()
+
+	rules := payload.Rules
+	if len(rules) == 0 {
+		saved, err := app.store.AutoMod.GetRules(ctx, community.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		rules = saved
+	}
+
+	ruleset, err := automod.Parse(rules)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	posts, err := app.store.AutoMod.GetRecentPosts(ctx, community.ID, payload.Limit)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := DryRunResponse{
+		Evaluated: len(posts),
+		Matches:   []DryRunMatch{},
+	}
+
+	for _, post := range posts {
+		matched := ruleset.Evaluate(automod.Subject{
+			Type:       automod.TargetPost,
+			Title:      post.Title,
+			Content:    post.Content,
+			Tags:       post.Tags,
+			AccountAge: time.Since(post.Author.CreatedAt),
+			Karma:      post.Author.Karma,
+		})
+		if len(matched) == 0 {
+			continue
+		}
+
+		response.Matches = append(response.Matches, DryRunMatch{
+			PostID: post.ID,
+			Title:  post.Title,
+			Slug:   post.Slug,
+			Rules:  matched,
+		})
+	}
+
+	if err = jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type autoModTarget struct {
+	Type    string
+	ID      int64
+	PostID  int64
+	Label   string
+	Subject automod.Subject
+}
+
+// runAutoMod evaluates the community's rules against newly published content
+// and applies the matching actions, reporting whether the content was
+// removed. Content by moderators is exempt, and failures are only logged so a
+// broken rule never blocks posting.
+func (app *application) runAutoMod(ctx context.Context, community *store.CommunityDetails, user *store.UserDetails, target autoModTarget) bool {
+	isModerator, err := app.holdsCommunityRole(ctx, community, user, "moderator")
+	if err != nil {
+		app.logger.Errorw("error checking moderator role", "user", user.ID, "error", err)
+		return false
+	}
+
+	if isModerator {
+		return false
+	}
+
+	data, err := app.store.AutoMod.GetRules(ctx, community.ID)
+	if err != nil {
+		app.logger.Errorw("error loading automod rules", "community", community.ID, "error", err)
+		return false
+	}
+
+	ruleset, err := automod.Parse(data)
+	if err != nil {
+		app.logger.Errorw("error parsing automod rules", "community", community.ID, "error", err)
+		return false
+	}
+
+	if len(ruleset.Rules()) == 0 {
+		return false
+	}
+
+	standing, err := app.store.AutoMod.GetAuthorStanding(ctx, user.ID)
+	if err != nil {
+		app.logger.Errorw("error loading author standing", "user", user.ID, "error", err)
+		return false
+	}
+
+	target.Subject.AccountAge = time.Since(standing.CreatedAt)
+	target.Subject.Karma = standing.Karma
+
+	matched := ruleset.Evaluate(target.Subject)
+
+	// Content is removed at most once, and reporting removed content to the
+	// mod queue would only add noise.
+	removed := false
+
+	for _, rule := range matched {
+		err = nil
+
+		switch rule.Action {
+		case automod.ActionRemove:
+			if removed {
+				continue
+			}
+
+			action := store.ModActionRemovePost
+			if target.Type == automod.TargetComment {
+				action = store.ModActionRemoveComment
+			}
+
+			entry := autoModLogEntry(community, target, action, rule)
+			if err = app.store.ModQueue.Remove(ctx, target.Type, target.ID, nil, entry); err == nil {
+				removed = true
+			}
+		case automod.ActionLock:
+			entry := autoModLogEntry(community, target, store.ModActionLockPost, rule)
+			err = app.store.Posts.SetLocked(ctx, target.PostID, true, entry)
+		case automod.ActionReply:
+			err = app.autoModReply(ctx, target, rule.Reply)
+		}
+
+		if err != nil {
+			app.logger.Errorw("error applying automod rule", "rule", rule.Name, "action", rule.Action, "error", err)
+		}
+	}
+
+	if removed {
+		return false
+	}
+
+	for _, rule := range matched {
+		if rule.Action != automod.ActionReport {
+			continue
+		}
+
+		err = app.store.Reports.Create(ctx, &store.Report{
+			CommunityID: community.ID,
+			TargetType:  target.Type,
+			TargetID:    target.ID,
+			Reason:      rule.Name,
+			Details:     rule.Reason,
+		})
+		if err != nil {
+			app.logger.Errorw("error applying automod rule", "rule", rule.Name, "action", rule.Action, "error", err)
+		}
+	}
+
+	return false
+}
+
+func (app *application) autoModReply(ctx context.Context, target autoModTarget, content string) error {
+	bot, err := app.store.Users.GetByUsername(ctx, automod.Username)
+	if err != nil {
+		return err
+	}
+
+	comment := &store.Comment{
+		Content: content,
+		PostID:  target.PostID,
+		UserID:  bot.ID,
+	}
+
+	if target.Type == automod.TargetComment {
+		comment.ParentID = &target.ID
+	}
+
+	return app.store.Comments.Create(ctx, comment)
+}
+
+// autoModLogEntry describes an action AutoModerator took on its own, it has
+// no actor.
+func autoModLogEntry(community *store.CommunityDetails, target autoModTarget, action string, rule automod.Rule) *store.ModLogEntry {
+	return &store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      action,
+		TargetType:  target.Type,
+		TargetID:    &target.ID,
+		TargetLabel: target.Label,
+		Reason:      rule.Name,
+		Automated:   true,
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/mailer"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+func (app *application) getCommunityBansHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	bans, err := app.store.Bans.GetCommunityBans(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range bans {
+		bans[i].User.AvatarURL = app.generateAssetURL(bans[i].User.AvatarID, "avatars")
+	}
+
+	if err = jsonResponse(w, http.StatusOK, bans); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateBanPayload struct {
+	Username      string `json:"username" validate:"required,max=100"`
+	Type          string `json:"type" validate:"required,oneof=ban mute"`
+	Reason        string `json:"reason" validate:"max=255"`
+	DurationHours *int   `json:"durationHours" validate:"omitempty,min=1,max=8760"`
+	Notify        bool   `json:"notify"`
+}
+
+func (app *application) createBanHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateBanPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	target, err := app.store.Users.GetByUsername(ctx, payload.Username)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	targetLevel := 0
+	member, err := app.store.Members.GetByUsername(ctx, community.ID, target.Username)
+	switch err {
+	case nil:
+		targetLevel = member.Role.Level
+	case store.ErrNotFound:
+	default:
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = app.checkCanManage(r, target.ID, targetLevel); err != nil {
+		app.specificForbiddenResponse(w, r, err)
+		return
+	}
+
+	var exp *time.Duration
+	if payload.DurationHours != nil {
+		d := time.Hour * time.Duration(*payload.DurationHours)
+		exp = &d
+	}
+
+	ban := &store.Ban{
+		CommunityID: community.ID,
+		User:        store.UserOverview{BaseUser: target.BaseUser},
+		ModeratorID: &user.ID,
+		Type:        payload.Type,
+		Reason:      payload.Reason,
+	}
+
+	if err = app.store.Bans.Create(ctx, ban, exp, modLogEntry(r, banLogEntry(ban))); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if payload.Notify {
+		app.sendBanEmail(r, community.Name, ban)
+	}
+
+	ban.User.AvatarURL = app.generateAssetURL(ban.User.AvatarID, "avatars")
+
+	if err = jsonResponse(w, http.StatusCreated, ban); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deleteBanHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionLiftBan,
+		TargetType:  "ban",
+		TargetID:    &id,
+	})
+
+	if err = app.store.Bans.Delete(r.Context(), id, community.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireNotRestricted stops banned and muted users from contributing to a
+// community, joining is handled separately as mutes still allow membership.
+func (app *application) requireNotRestricted(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+
+		if !app.checkNotRestricted(w, r, user.ID) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkNotRestricted reports whether the user is free to contribute to the
+// community, writing the error response when they're banned or muted.
+func (app *application) checkNotRestricted(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	community := getCommunityFromContext(r)
+
+	ban, err := app.store.Bans.GetActive(r.Context(), community.ID, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			return true
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return false
+	}
+
+	app.specificForbiddenResponse(w, r, banError(ban))
+	return false
+}
+
+func banError(ban *store.Ban) error {
+	msg := fmt.Sprintf("you are %s this community", banAction(ban))
+	if ban.Expiry != nil {
+		msg = fmt.Sprintf("%s until %s", msg, *ban.Expiry)
+	}
+	if ban.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, ban.Reason)
+	}
+
+	return fmt.Errorf("%s", msg)
+}
+
+func banLogEntry(ban *store.Ban) store.ModLogEntry {
+	action := store.ModActionBanUser
+	if ban.Type == store.BanTypeMute {
+		action = store.ModActionMuteUser
+	}
+
+	return store.ModLogEntry{
+		CommunityID: &ban.CommunityID,
+		Action:      action,
+		TargetType:  "user",
+		TargetID:    &ban.User.ID,
+		TargetLabel: ban.User.Username,
+		Reason:      ban.Reason,
+	}
+}
+
+func banAction(ban *store.Ban) string {
+	if ban.Type == store.BanTypeMute {
+		return "muted in"
+	}
+	return "banned from"
+}
+
+func (app *application) sendBanEmail(r *http.Request, communityName string, ban *store.Ban) {
+	user, err := app.store.Users.GetByID(r.Context(), ban.User.ID)
+	if err != nil {
+		app.logger.Errorw("error finding banned user", "error", err)
+		return
+	}
+
+	until := "further notice"
+	if ban.Expiry != nil {
+		until = *ban.Expiry
+	}
+
+	vars := struct {
+		Username  string
+		Community string
+		Action    string
+		Reason    string
+		Until     string
+	}{
+		Username:  user.Username,
+		Community: communityName,
+		Action:    banAction(ban),
+		Reason:    ban.Reason,
+		Until:     until,
+	}
+
+	isProd := app.config.env == "production"
+
+	statusCode, err := app.mailer.Send(mailer.CommunityBanTemplate, user.Username, user.Email, vars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending ban email", "error", err)
+		return
+	}
+
+	app.logger.Infow("ban email sent", "status code", statusCode)
+}
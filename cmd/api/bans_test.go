@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockBanStore struct {
+	store.BanStore
+	active  *store.Ban
+	created *store.Ban
+	entry   *store.ModLogEntry
+}
+
+func (m *mockBanStore) GetActive(ctx context.Context, communityID, userID int64) (*store.Ban, error) {
+	if m.active == nil {
+		return nil, store.ErrNotFound
+	}
+	return m.active, nil
+}
+
+func (m *mockBanStore) Create(ctx context.Context, ban *store.Ban, exp *time.Duration, entry *store.ModLogEntry) error {
+	m.created = ban
+	m.entry = entry
+	return nil
+}
+
+type mockMemberStore struct {
+	store.MemberStore
+	members map[string]*store.Member
+}
+
+func (m *mockMemberStore) GetByUsername(ctx context.Context, communityID int64, username string) (*store.Member, error) {
+	member, ok := m.members[username]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return member, nil
+}
+
+type mockUsernameStore struct {
+	store.MockUserStore
+	users map[string]*store.UserSummary
+}
+
+func (m *mockUsernameStore) GetByUsername(ctx context.Context, username string) (*store.UserSummary, error) {
+	user, ok := m.users[username]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func newTestMember(id int64, username, role string) *store.Member {
+	member := &store.Member{Role: testRoles[role]}
+	member.User.ID = id
+	member.User.Username = username
+	return member
+}
+
+func TestRequireNotRestricted(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name     string
+		active   *store.Ban
+		expected int
+		message  string
+	}{
+		{"should let unrestricted users through", nil, http.StatusOK, ""},
+		{"should stop banned users", &store.Ban{Type: store.BanTypeBan}, http.StatusForbidden, "banned from"},
+		{"should stop muted users", &store.Ban{Type: store.BanTypeMute, Reason: "spam"}, http.StatusForbidden, "muted in this community: spam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.store.Bans = &mockBanStore{active: tt.active}
+
+			req := newCommunityRequest(http.MethodPost, &store.CommunityDetails{}, &store.UserDetails{}, nil)
+			rr := httptest.NewRecorder()
+
+			app.requireNotRestricted(ok)(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if !strings.Contains(rr.Body.String(), tt.message) {
+				t.Errorf("expected %q in %s", tt.message, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateBan(t *testing.T) {
+	members := map[string]*store.Member{
+		"creator":   newTestMember(1, "creator", "admin"),
+		"member":    newTestMember(2, "member", "member"),
+		"moderator": newTestMember(3, "moderator", "moderator"),
+		"peer":      newTestMember(4, "peer", "moderator"),
+	}
+
+	users := map[string]*store.UserSummary{}
+	for username, member := range members {
+		users[username] = &store.UserSummary{BaseUser: member.User.BaseUser}
+	}
+	users["outsider"] = &store.UserSummary{}
+	users["outsider"].ID = 5
+	users["outsider"].Username = "outsider"
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		action   string
+	}{
+		{"should ban a member", `{"username": "member", "type": "ban"}`, http.StatusCreated, store.ModActionBanUser},
+		{"should mute a member", `{"username": "member", "type": "mute"}`, http.StatusCreated, store.ModActionMuteUser},
+		{"should ban a user outside the community", `{"username": "outsider", "type": "ban"}`, http.StatusCreated, store.ModActionBanUser},
+		{"should not ban the creator", `{"username": "creator", "type": "ban"}`, http.StatusForbidden, ""},
+		{"should not ban themselves", `{"username": "moderator", "type": "ban"}`, http.StatusForbidden, ""},
+		{"should not ban a moderator of the same rank", `{"username": "peer", "type": "ban"}`, http.StatusForbidden, ""},
+		{"should not find an unknown user", `{"username": "nobody", "type": "ban"}`, http.StatusNotFound, ""},
+		{"should reject an unknown type", `{"username": "member", "type": "kick"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			bans := &mockBanStore{}
+			app.store.Bans = bans
+			app.store.Members = &mockMemberStore{members: members}
+			app.store.Users = &mockUsernameStore{users: users}
+
+			community := &store.CommunityDetails{}
+			community.ID = 1
+			community.UserID = 1
+			community.Role = testRoles["moderator"]
+			user := &store.UserDetails{Role: testRoles["user"]}
+			user.ID = 3
+
+			req := withBody(newCommunityRequest(http.MethodPost, community, user, nil), tt.body)
+			rr := httptest.NewRecorder()
+
+			app.createBanHandler(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if tt.action == "" {
+				if bans.created != nil {
+					t.Error("expected no ban to be created")
+				}
+				return
+			}
+
+			if bans.entry == nil || bans.entry.Action != tt.action || *bans.entry.ActorID != user.ID {
+				t.Errorf("expected a %s entry by the moderator, got %+v", tt.action, bans.entry)
+			}
+		})
+	}
+}
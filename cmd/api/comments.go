@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/automod"
 	"github.com/skiba-mateusz/communiverse/internal/store"
 )
 
@@ -62,6 +63,17 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	app.runAutoMod(ctx, getCommunityFromContext(r), user, autoModTarget{
+		Type:   automod.TargetComment,
+		ID:     comment.ID,
+		PostID: post.ID,
+		Label:  user.Username,
+		Subject: automod.Subject{
+			Type:    automod.TargetComment,
+			Content: comment.Content,
+		},
+	})
+
 	if err := jsonResponse(w, http.StatusCreated, comment); err != nil {
 		app.internalServerError(w, r, err)
 	}
@@ -110,7 +122,22 @@ func (app *application) updateCommentHandler(w http.ResponseWriter, r *http.Requ
 		comment.Content = *payload.Content
 	}
 
-	if err := app.store.Comments.Update(r.Context(), comment); err != nil {
+	user := getUserFromContext(r)
+
+	// Admins editing someone else's comment are moderating it.
+	var entry *store.ModLogEntry
+	if user.ID != comment.UserID {
+		community := getCommunityFromContext(r)
+		entry = modLogEntry(r, store.ModLogEntry{
+			CommunityID: &community.ID,
+			Action:      store.ModActionEditComment,
+			TargetType:  "comment",
+			TargetID:    &comment.ID,
+			TargetLabel: comment.User.Username,
+		})
+	}
+
+	if err := app.store.Comments.Update(r.Context(), comment, user.ID, entry); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -133,7 +160,34 @@ func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err = app.store.Comments.Delete(r.Context(), id); err != nil {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	comment := getCommentFromContext(r)
+	user := getUserFromContext(r)
+	isAuthor := user.ID == comment.UserID
+
+	// Authors delete their own comments, anyone else deleting it is a moderator
+	// removing it.
+	if isAuthor {
+		err = app.store.Comments.Delete(r.Context(), id)
+	} else {
+		community := getCommunityFromContext(r)
+		entry := modLogEntry(r, store.ModLogEntry{
+			CommunityID: &community.ID,
+			Action:      store.ModActionRemoveComment,
+			TargetType:  "comment",
+			TargetID:    &comment.ID,
+			TargetLabel: comment.User.Username,
+			Reason:      reason,
+		})
+
+		err = app.store.ModQueue.Remove(r.Context(), "comment", id, &user.ID, entry)
+	}
+	if err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -197,6 +251,11 @@ func (app *application) commentContextMiddleware(next http.Handler) http.Handler
 			return
 		}
 
+		if comment.PostID != getPostFromContext(r).ID {
+			app.notFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+
 		ctx = context.WithValue(ctx, commentCtx, comment)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -218,12 +277,33 @@ func buildNestedComments(comments []store.Comment) []store.Comment {
 
 	for _, c := range comments {
 		if c.ParentID != nil {
-			parentComment := commentsMap[*c.ParentID]
+			parentComment, ok := commentsMap[*c.ParentID]
+			if !ok {
+				continue
+			}
 			parentComment.Replies = append(parentComment.Replies, *commentsMap[c.ID])
 		} else {
 			rootComments = append(rootComments, *commentsMap[c.ID])
 		}
 	}
 
-	return rootComments
+	return pruneTombstones(rootComments)
+}
+
+// pruneTombstones drops deleted and removed comments that no longer hold up
+// any replies.
+func pruneTombstones(comments []store.Comment) []store.Comment {
+	pruned := []store.Comment{}
+
+	for _, c := range comments {
+		c.Replies = pruneTombstones(c.Replies)
+
+		if c.Tombstone != "" && len(c.Replies) == 0 {
+			continue
+		}
+
+		pruned = append(pruned, c)
+	}
+
+	return pruned
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockCommentStore struct {
+	store.CommentStore
+	comment *store.Comment
+}
+
+func (m *mockCommentStore) GetByID(ctx context.Context, commentID, userID int64) (*store.Comment, error) {
+	if m.comment == nil || m.comment.ID != commentID {
+		return nil, store.ErrNotFound
+	}
+	return m.comment, nil
+}
+
+func TestBuildNestedComments(t *testing.T) {
+	parent := func(id int64) *int64 { return &id }
+
+	// Newest first, as returned by the store.
+	comments := []store.Comment{
+		{ID: 5, ParentID: parent(4), Tombstone: store.TombstoneDeleted},
+		{ID: 4, Tombstone: store.TombstoneRemoved},
+		{ID: 3, ParentID: parent(1)},
+		{ID: 2, Tombstone: store.TombstoneDeleted},
+		{ID: 1, Tombstone: store.TombstoneDeleted},
+	}
+
+	nested := buildNestedComments(comments)
+
+	if len(nested) != 1 || nested[0].ID != 1 {
+		t.Fatalf("expected only the tombstone with replies to remain, got %+v", nested)
+	}
+
+	if len(nested[0].Replies) != 1 || nested[0].Replies[0].ID != 3 {
+		t.Fatalf("expected the reply to stay under its deleted parent, got %+v", nested[0].Replies)
+	}
+}
+
+func TestCommentContextMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		postID   int64
+		expected int
+	}{
+		{"should load a comment of the post", 1, http.StatusOK},
+		{"should hide a comment of another post", 2, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.store.Comments = &mockCommentStore{comment: &store.Comment{ID: 7, PostID: 1}}
+
+			post := &store.PostDetails{}
+			post.ID = tt.postID
+
+			req := newCommunityRequest(http.MethodGet, &store.CommunityDetails{}, &store.UserDetails{}, post)
+			req = withURLParams(req, "id", "7")
+			rr := httptest.NewRecorder()
+
+			app.commentContextMiddleware(ok).ServeHTTP(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"image"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/skiba-mateusz/communiverse/internal/store"
@@ -16,9 +18,12 @@ const (
 	communityCtx communityKey = "community"
 )
 
+var errPrivateCommunity = fmt.Errorf("this community is private, join it to see its content")
+
 type CreateCommunityPayload struct {
 	Name        string `json:"name" validate:"required,min=8,max=100"`
 	Description string `json:"description" validate:"required,min=32,max=255"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=public restricted private"`
 }
 
 func (app *application) createCommunityHandler(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +35,11 @@ func (app *application) createCommunityHandler(w http.ResponseWriter, r *http.Re
 	payload := CreateCommunityPayload{
 		Name:        r.FormValue("name"),
 		Description: r.FormValue("description"),
+		Visibility:  r.FormValue("visibility"),
+	}
+
+	if payload.Visibility == "" {
+		payload.Visibility = "public"
 	}
 
 	if err := Validate.Struct(payload); err != nil {
@@ -85,6 +95,7 @@ func (app *application) createCommunityHandler(w http.ResponseWriter, r *http.Re
 			Name: "admin",
 		},
 		Description:  payload.Description,
+		Visibility:   payload.Visibility,
 		UserID:       user.ID,
 	}
 
@@ -101,6 +112,14 @@ func (app *application) createCommunityHandler(w http.ResponseWriter, r *http.Re
 func (app *application) getCommunityHandler(w http.ResponseWriter, r *http.Request) {
 	community := getCommunityFromContext(r)
 
+	rules, err := app.store.Rules.GetCommunityRules(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	community.Rules = rules
+
 	if err := jsonResponse(w, http.StatusOK, community); err != nil {
 		app.internalServerError(w, r, err)
 	}
@@ -109,9 +128,25 @@ func (app *application) getCommunityHandler(w http.ResponseWriter, r *http.Reque
 func (app *application) deleteCommunityHandler(w http.ResponseWriter, r *http.Request) {
 	community := getCommunityFromContext(r)
 
-	ctx := r.Context()
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	entry := &store.ModLogEntry{
+		CommunityID: &community.ID,
+		ActorID:     &user.ID,
+		Action:      store.ModActionDeleteCommunity,
+		TargetType:  "community",
+		TargetID:    &community.ID,
+		TargetLabel: community.Name,
+		Reason:      reason,
+	}
 
-	if err := app.store.Communities.Delete(ctx, community.ID); err != nil {
+	if err = app.store.Communities.Delete(r.Context(), community.ID, entry); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -125,8 +160,10 @@ func (app *application) deleteCommunityHandler(w http.ResponseWriter, r *http.Re
 }
 
 type UpdateCommunityPayload struct {
-	Name        *string `json:"name" validate:"omitempty,min=8,max=100"`
-	Description *string `json:"description" validate:"omitempty,min=32,max=255"`
+	Name         *string `json:"name" validate:"omitempty,min=8,max=100"`
+	Description  *string `json:"description" validate:"omitempty,min=32,max=255"`
+	Visibility   *string `json:"visibility" validate:"omitempty,oneof=public restricted private"`
+	PublicModLog *string `json:"publicModLog" validate:"omitempty,oneof=true false"`
 }
 
 func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Request) {
@@ -136,8 +173,10 @@ func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	payload := UpdateCommunityPayload{
-		Name:        getStringPointer(r.FormValue("name")),
-		Description: getStringPointer(r.FormValue("description")),
+		Name:         getStringPointer(r.FormValue("name")),
+		Description:  getStringPointer(r.FormValue("description")),
+		Visibility:   getStringPointer(r.FormValue("visibility")),
+		PublicModLog: getStringPointer(r.FormValue("publicModLog")),
 	}
 
 	if err := Validate.Struct(payload); err != nil {
@@ -148,6 +187,16 @@ func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Re
 	community := getCommunityFromContext(r)
 	ctx := r.Context()
 
+	// Who can see the community and its mod log are logged, cosmetic changes
+	// aren't.
+	var changes []string
+	if payload.Visibility != nil && *payload.Visibility != community.Visibility {
+		changes = append(changes, fmt.Sprintf("visibility: %s -> %s", community.Visibility, *payload.Visibility))
+	}
+	if payload.PublicModLog != nil && (*payload.PublicModLog == "true") != community.PublicModLog {
+		changes = append(changes, fmt.Sprintf("public mod log: %t -> %t", community.PublicModLog, !community.PublicModLog))
+	}
+
 	if payload.Name != nil {
 		community.Name = *payload.Name
 
@@ -162,6 +211,12 @@ func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Re
 	if payload.Description != nil {
 		community.Description = *payload.Description
 	}
+	if payload.Visibility != nil {
+		community.Visibility = *payload.Visibility
+	}
+	if payload.PublicModLog != nil {
+		community.PublicModLog = *payload.PublicModLog == "true"
+	}
 
 	file, _, err := r.FormFile("thumbnail")
 	if err != nil && err != http.ErrMissingFile {
@@ -192,7 +247,19 @@ func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Re
 		community.ThumbnailURL = app.generateAssetURL(id, "thumbnails")
 	}
 
-	if err = app.store.Communities.Update(ctx, community); err != nil {
+	var entry *store.ModLogEntry
+	if len(changes) > 0 {
+		entry = modLogEntry(r, store.ModLogEntry{
+			CommunityID: &community.ID,
+			Action:      store.ModActionUpdateSettings,
+			TargetType:  "community",
+			TargetID:    &community.ID,
+			TargetLabel: community.Name,
+			Reason:      strings.Join(changes, ", "),
+		})
+	}
+
+	if err = app.store.Communities.Update(ctx, community, entry); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -210,8 +277,57 @@ func (app *application) updateCommunityHandler(w http.ResponseWriter, r *http.Re
 func (app *application) joinCommunityHandler(w http.ResponseWriter, r *http.Request) {
 	community := getCommunityFromContext(r)
 	user := getUserFromContext(r)
+	ctx := r.Context()
 
-	if err := app.store.Communities.Join(r.Context(), community.ID, user.ID, "member"); err != nil {
+	ban, err := app.store.Bans.GetActive(ctx, community.ID, user.ID)
+	if err != nil && err != store.ErrNotFound {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if ban != nil && ban.Type == store.BanTypeBan {
+		app.specificForbiddenResponse(w, r, banError(ban))
+		return
+	}
+
+	if community.Role.ID != -1 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if invite := r.URL.Query().Get("invite"); invite != "" {
+		if err := app.store.Invites.Accept(ctx, invite, community.ID, user.ID); err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errInvalidInvite)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if community.Visibility != "public" {
+		request := &store.JoinRequest{
+			CommunityID: community.ID,
+			User:        store.UserOverview{BaseUser: user.BaseUser},
+		}
+
+		if err := app.store.JoinRequests.Create(ctx, request); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if err := jsonResponse(w, http.StatusAccepted, request); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.Communities.Join(ctx, community.ID, user.ID, "member"); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -224,6 +340,7 @@ func (app *application) leaveCommunityHandler(w http.ResponseWriter, r *http.Req
 	user := getUserFromContext(r)
 
 	if user.ID == community.UserID {
+		app.specificForbiddenResponse(w, r, errOwnerLeave)
 		return
 	}
 
@@ -300,6 +417,34 @@ func (app *application) communityContextMiddleware(next http.Handler) http.Handl
 	})
 }
 
+// requireCommunityAccess hides the content of private communities from
+// everyone but their members and staff.
+func (app *application) requireCommunityAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		community := getCommunityFromContext(r)
+
+		if community.Visibility != "private" || community.Role.ID != -1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := getUserFromContext(r)
+
+		allowed, err := app.checkRole(r.Context(), user.Role, "staff")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.specificForbiddenResponse(w, r, errPrivateCommunity)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func getCommunityFromContext(r *http.Request) *store.CommunityDetails {
 	community := r.Context().Value(communityCtx).(*store.CommunityDetails)
 	return community
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockCommunityStore struct {
+	store.CommunityStore
+	joined bool
+}
+
+func (m *mockCommunityStore) Join(ctx context.Context, communityID, userID int64, role string) error {
+	m.joined = true
+	return nil
+}
+
+type mockJoinRequestStore struct {
+	store.JoinRequestStore
+	requested bool
+	err       error
+	entry     *store.ModLogEntry
+}
+
+func (m *mockJoinRequestStore) Create(ctx context.Context, request *store.JoinRequest) error {
+	m.requested = true
+	return nil
+}
+
+func (m *mockJoinRequestStore) Approve(ctx context.Context, id, communityID int64, entry *store.ModLogEntry) error {
+	m.entry = entry
+	return m.err
+}
+
+func (m *mockJoinRequestStore) Delete(ctx context.Context, id, communityID int64, entry *store.ModLogEntry) error {
+	m.entry = entry
+	return m.err
+}
+
+func TestRequireCommunityAccess(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		visibility    string
+		role          string
+		communityRole store.Role
+		expected      int
+	}{
+		{"should show a public community to outsiders", "public", "user", noCommunityRole, http.StatusOK},
+		{"should show a restricted community to outsiders", "restricted", "user", noCommunityRole, http.StatusOK},
+		{"should show a private community to members", "private", "user", testRoles["member"], http.StatusOK},
+		{"should hide a private community from outsiders", "private", "user", noCommunityRole, http.StatusForbidden},
+		{"should hide a private community from site admins", "private", "admin", noCommunityRole, http.StatusForbidden},
+		{"should show a private community to staff", "private", "staff", noCommunityRole, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplicationWithRoles(t)
+
+			community := &store.CommunityDetails{Visibility: tt.visibility, Role: tt.communityRole}
+			user := &store.UserDetails{Role: testRoles[tt.role]}
+
+			req := newCommunityRequest(http.MethodGet, community, user, nil)
+			rr := httptest.NewRecorder()
+
+			app.requireCommunityAccess(ok).ServeHTTP(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func TestJoinCommunity(t *testing.T) {
+	tests := []struct {
+		name          string
+		visibility    string
+		communityRole store.Role
+		active        *store.Ban
+		expected      int
+		joined        bool
+		requested     bool
+	}{
+		{"should join a public community", "public", noCommunityRole, nil, http.StatusNoContent, true, false},
+		{"should let muted users join", "public", noCommunityRole, &store.Ban{Type: store.BanTypeMute}, http.StatusNoContent, true, false},
+		{"should not let banned users join", "public", noCommunityRole, &store.Ban{Type: store.BanTypeBan}, http.StatusForbidden, false, false},
+		{"should request to join a private community", "private", noCommunityRole, nil, http.StatusAccepted, false, true},
+		{"should request to join a restricted community", "restricted", noCommunityRole, nil, http.StatusAccepted, false, true},
+		{"should not request to join as a banned user", "private", noCommunityRole, &store.Ban{Type: store.BanTypeBan}, http.StatusForbidden, false, false},
+		{"should do nothing for members", "private", testRoles["member"], nil, http.StatusNoContent, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			communities := &mockCommunityStore{}
+			requests := &mockJoinRequestStore{}
+			app.store.Communities = communities
+			app.store.JoinRequests = requests
+			app.store.Bans = &mockBanStore{active: tt.active}
+
+			community := &store.CommunityDetails{Visibility: tt.visibility, Role: tt.communityRole}
+
+			req := newCommunityRequest(http.MethodPost, community, &store.UserDetails{}, nil)
+			rr := httptest.NewRecorder()
+
+			app.joinCommunityHandler(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if communities.joined != tt.joined {
+				t.Errorf("expected joined to be %t", tt.joined)
+			}
+
+			if requests.requested != tt.requested {
+				t.Errorf("expected requested to be %t", tt.requested)
+			}
+		})
+	}
+}
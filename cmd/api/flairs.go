@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var (
+	errUnknownFlair   = fmt.Errorf("flair does not belong to this community")
+	errAdminOnlyFlair = fmt.Errorf("only community admins can use this flair")
+)
+
+type CreateFlairPayload struct {
+	Type            string `json:"type" validate:"required,oneof=post user"`
+	Label           string `json:"label" validate:"required,max=50"`
+	TextColor       string `json:"textColor" validate:"required,hexcolor"`
+	BackgroundColor string `json:"backgroundColor" validate:"required,hexcolor"`
+	AdminOnly       bool   `json:"adminOnly"`
+}
+
+type UpdateFlairPayload struct {
+	Label           *string `json:"label" validate:"omitempty,min=1,max=50"`
+	TextColor       *string `json:"textColor" validate:"omitempty,hexcolor"`
+	BackgroundColor *string `json:"backgroundColor" validate:"omitempty,hexcolor"`
+	AdminOnly       *bool   `json:"adminOnly"`
+}
+
+type UserFlairPayload struct {
+	FlairID *int64 `json:"flairID" validate:"omitempty,min=1"`
+}
+
+func (app *application) getFlairsHandler(w http.ResponseWriter, r *http.Request) {
+	flairType := r.URL.Query().Get("type")
+	if err := Validate.Var(flairType, "omitempty,oneof=post user"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	flairs, err := app.store.Flairs.GetCommunityFlairs(r.Context(), community.ID, flairType)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, flairs); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) createFlairHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateFlairPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	flair := &store.Flair{
+		FlairOverview: store.FlairOverview{
+			Label:           payload.Label,
+			TextColor:       payload.TextColor,
+			BackgroundColor: payload.BackgroundColor,
+		},
+		CommunityID: community.ID,
+		Type:        payload.Type,
+		AdminOnly:   payload.AdminOnly,
+	}
+
+	// The store fills in the flair id before it writes the entry.
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionCreateFlair,
+		TargetType:  "flair",
+		TargetID:    &flair.ID,
+		TargetLabel: flair.Label,
+	})
+
+	if err := app.store.Flairs.Create(r.Context(), flair, entry); err != nil {
+		switch err {
+		case store.ErrDuplicateFlair:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusCreated, flair); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) updateFlairHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateFlairPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	ctx := r.Context()
+
+	flair, err := app.store.Flairs.GetByID(ctx, id, community.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.Label != nil {
+		flair.Label = *payload.Label
+	}
+	if payload.TextColor != nil {
+		flair.TextColor = *payload.TextColor
+	}
+	if payload.BackgroundColor != nil {
+		flair.BackgroundColor = *payload.BackgroundColor
+	}
+	if payload.AdminOnly != nil {
+		flair.AdminOnly = *payload.AdminOnly
+	}
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionUpdateFlair,
+		TargetType:  "flair",
+		TargetID:    &flair.ID,
+		TargetLabel: flair.Label,
+	})
+
+	if err = app.store.Flairs.Update(ctx, flair, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrDuplicateFlair:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, flair); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deleteFlairHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionDeleteFlair,
+		TargetType:  "flair",
+		TargetID:    &id,
+	})
+
+	if err = app.store.Flairs.Delete(r.Context(), id, community.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setUserFlairHandler lets members pick their own flair in the community,
+// a missing flair clears it.
+func (app *application) setUserFlairHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	app.setMemberFlair(w, r, user.ID, nil)
+}
+
+func (app *application) setMemberFlairHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	member, err := app.store.Members.GetByUsername(r.Context(), community.ID, chi.URLParam(r, "username"))
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionSetMemberFlair,
+		TargetType:  "user",
+		TargetID:    &member.User.ID,
+		TargetLabel: member.User.Username,
+	})
+
+	app.setMemberFlair(w, r, member.User.ID, entry)
+}
+
+// setMemberFlair sets the flair of a member, entry is nil when members set
+// their own.
+func (app *application) setMemberFlair(w http.ResponseWriter, r *http.Request, userID int64, entry *store.ModLogEntry) {
+	var payload UserFlairPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	flair, ok := app.getUsableFlair(w, r, payload.FlairID, store.FlairTypeUser)
+	if !ok {
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	var flairID *int64
+	if flair != nil {
+		flairID = &flair.ID
+	}
+
+	if entry != nil && flair != nil {
+		entry.Reason = flair.Label
+	}
+
+	if err := app.store.Flairs.SetUserFlair(r.Context(), community.ID, userID, flairID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, flair); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getUsableFlair resolves a flair of the given type in the current community
+// and checks the current user may apply it. A nil id resolves to no flair.
+func (app *application) getUsableFlair(w http.ResponseWriter, r *http.Request, id *int64, flairType string) (*store.FlairOverview, bool) {
+	if id == nil || *id == 0 {
+		return nil, true
+	}
+
+	community := getCommunityFromContext(r)
+
+	flair, err := app.store.Flairs.GetByID(r.Context(), *id, community.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errUnknownFlair)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if flair.Type != flairType {
+		app.badRequestResponse(w, r, errUnknownFlair)
+		return nil, false
+	}
+
+	if flair.AdminOnly {
+		isAdmin, err := app.hasCommunityRole(r, "admin")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return nil, false
+		}
+
+		if !isAdmin {
+			app.specificForbiddenResponse(w, r, errAdminOnlyFlair)
+			return nil, false
+		}
+	}
+
+	return &flair.FlairOverview, true
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var errInvalidInvite = fmt.Errorf("invite is invalid, expired or has been used up")
+
+type CreateInvitePayload struct {
+	MaxUses        *int `json:"maxUses" validate:"omitempty,min=1,max=1000"`
+	ExpiresInHours *int `json:"expiresInHours" validate:"omitempty,min=1,max=720"`
+}
+
+type CreateInviteResponse struct {
+	store.Invite
+	Token     string `json:"token"`
+	InviteURL string `json:"inviteURL"`
+}
+
+func (app *application) createInviteHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateInvitePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	var exp *time.Duration
+	if payload.ExpiresInHours != nil {
+		d := time.Hour * time.Duration(*payload.ExpiresInHours)
+		exp = &d
+	}
+
+	invite := &store.Invite{
+		CommunityID: community.ID,
+		UserID:      user.ID,
+		MaxUses:     payload.MaxUses,
+	}
+
+	plainToken, hashToken := generateTokenAndHash()
+
+	// The store fills in the invite id before it writes the entry.
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionCreateInvite,
+		TargetType:  "invite",
+		TargetID:    &invite.ID,
+	})
+
+	if err := app.store.Invites.Create(r.Context(), invite, hashToken, exp, entry); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := CreateInviteResponse{
+		Invite:    *invite,
+		Token:     plainToken,
+		InviteURL: fmt.Sprintf("%s/communities/%s/invite/%s", app.config.frontendURL, community.Slug, plainToken),
+	}
+
+	if err := jsonResponse(w, http.StatusCreated, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) getInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	invites, err := app.store.Invites.GetCommunityInvites(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, invites); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deleteInviteHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionDeleteInvite,
+		TargetType:  "invite",
+		TargetID:    &id,
+	})
+
+	if err = app.store.Invites.Delete(r.Context(), id, community.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/mailer"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+const renderBatchSize = 500
+
+func (app *application) runJobs(ctx context.Context) {
+	go app.runPeriodically(ctx, "purge inactive users", app.config.jobs.interval, app.purgeInactiveUsers)
+	go app.runPeriodically(ctx, "publish scheduled posts", app.config.jobs.publishInterval, app.publishScheduledPosts)
+	go app.runPeriodically(ctx, "purge tombstones", app.config.jobs.interval, app.purgeTombstones)
+	go app.runPeriodically(ctx, "render pending content", app.config.jobs.interval, app.renderPendingContent)
+	go app.runPeriodically(ctx, "prune auth attempts", app.config.jobs.interval, app.pruneAuthAttempts)
+}
+
+func (app *application) runPeriodically(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				app.logger.Errorw("background job failed", "job", name, "error", err)
+			}
+		}
+	}
+}
+
+func (app *application) purgeInactiveUsers(ctx context.Context) error {
+	deleted, err := app.store.Users.DeleteInactive(ctx, app.config.jobs.inactiveRetention)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		app.logger.Infow("purged inactive users", "count", deleted)
+	}
+
+	return nil
+}
+
+// pruneAuthAttempts deletes failed login attempts that no longer count towards
+// any limit. All limiters share one backend, so attempts are kept for the
+// widest window.
+func (app *application) pruneAuthAttempts(ctx context.Context) error {
+	p, ok := app.limiters.account.(pruner)
+	if !ok {
+		return nil
+	}
+
+	policies := app.config.throttle
+	window := max(policies.account.Window, policies.ip.Window, policies.email.Window)
+
+	pruned, err := p.Prune(ctx, window)
+	if err != nil {
+		return err
+	}
+
+	if pruned > 0 {
+		app.logger.Infow("pruned auth attempts", "count", pruned)
+	}
+
+	return nil
+}
+
+func (app *application) purgeTombstones(ctx context.Context) error {
+	purged, fileIDs, err := app.store.Tombstones.Purge(ctx, app.config.jobs.tombstoneRetention)
+	if err != nil {
+		return err
+	}
+
+	app.deleteMediaFiles(ctx, fileIDs)
+
+	if purged > 0 {
+		app.logger.Infow("purged tombstones", "count", purged)
+	}
+
+	return nil
+}
+
+// renderPendingContent backfills the HTML of posts and comments written before
+// Markdown was rendered on write.
+func (app *application) renderPendingContent(ctx context.Context) error {
+	var total int64
+
+	for {
+		rendered, err := app.store.Content.RenderPending(ctx, renderBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if rendered == 0 {
+			break
+		}
+
+		total += rendered
+	}
+
+	if total > 0 {
+		app.logger.Infow("rendered pending content", "count", total)
+	}
+
+	return nil
+}
+
+func (app *application) publishScheduledPosts(ctx context.Context) error {
+	posts, err := app.store.Posts.PublishScheduled(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		if app.autoModScheduledPost(ctx, post) {
+			continue
+		}
+
+		app.sendPublishedEmail(ctx, post)
+	}
+
+	if len(posts) > 0 {
+		app.logger.Infow("published scheduled posts", "count", len(posts))
+	}
+
+	return nil
+}
+
+// autoModScheduledPost runs AutoModerator on a scheduled post once it goes
+// live, reporting whether the post was removed. Scheduled posts are skipped
+// when they are created, as nobody else can see them until now.
+func (app *application) autoModScheduledPost(ctx context.Context, summary store.PostSummary) bool {
+	post, err := app.store.Posts.GetBySlug(ctx, summary.Slug, summary.UserID)
+	if err != nil {
+		app.logger.Errorw("error loading published post", "post", summary.ID, "error", err)
+		return false
+	}
+
+	community, err := app.store.Communities.GetBySlug(ctx, summary.Community.Slug, summary.UserID)
+	if err != nil {
+		app.logger.Errorw("error loading community", "community", summary.CommunityID, "error", err)
+		return false
+	}
+
+	author, err := app.store.Users.GetByID(ctx, summary.UserID)
+	if err != nil {
+		app.logger.Errorw("error loading post author", "user", summary.UserID, "error", err)
+		return false
+	}
+
+	return app.runPostAutoMod(ctx, community, author, post)
+}
+
+func (app *application) sendPublishedEmail(ctx context.Context, post store.PostSummary) {
+	user, err := app.store.Users.GetByID(ctx, post.UserID)
+	if err != nil {
+		app.logger.Errorw("error finding post author", "post", post.ID, "error", err)
+		return
+	}
+
+	vars := struct {
+		Username  string
+		Title     string
+		Community string
+		PostURL   string
+	}{
+		Username:  user.Username,
+		Title:     post.Title,
+		Community: post.Community.Name,
+		PostURL:   fmt.Sprintf("%s/communities/%s/posts/%s", app.config.frontendURL, post.Community.Slug, post.Slug),
+	}
+
+	isProd := app.config.env == "production"
+
+	statusCode, err := app.mailer.Send(mailer.PostPublishedTemplate, user.Username, user.Email, vars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending post published email", "error", err)
+		return
+	}
+
+	app.logger.Infow("post published email sent", "status code", statusCode)
+}
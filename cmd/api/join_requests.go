@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+func (app *application) getJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	requests, err := app.store.JoinRequests.GetCommunityRequests(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range requests {
+		requests[i].User.AvatarURL = app.generateAssetURL(requests[i].User.AvatarID, "avatars")
+	}
+
+	if err = jsonResponse(w, http.StatusOK, requests); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) approveJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionApproveJoin,
+		TargetType:  "join_request",
+		TargetID:    &id,
+	})
+
+	if err = app.store.JoinRequests.Approve(r.Context(), id, community.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrBanned:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) denyJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionDenyJoin,
+		TargetType:  "join_request",
+		TargetID:    &id,
+	})
+
+	if err = app.store.JoinRequests.Delete(r.Context(), id, community.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
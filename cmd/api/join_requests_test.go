@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+func TestReviewJoinRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  func(*application) http.HandlerFunc
+		err      error
+		expected int
+		action   string
+	}{
+		{"should approve a request", func(app *application) http.HandlerFunc { return app.approveJoinRequestHandler }, nil, http.StatusNoContent, store.ModActionApproveJoin},
+		{"should not approve a banned user", func(app *application) http.HandlerFunc { return app.approveJoinRequestHandler }, store.ErrBanned, http.StatusConflict, store.ModActionApproveJoin},
+		{"should not approve a missing request", func(app *application) http.HandlerFunc { return app.approveJoinRequestHandler }, store.ErrNotFound, http.StatusNotFound, store.ModActionApproveJoin},
+		{"should deny a request", func(app *application) http.HandlerFunc { return app.denyJoinRequestHandler }, nil, http.StatusNoContent, store.ModActionDenyJoin},
+		{"should not deny a missing request", func(app *application) http.HandlerFunc { return app.denyJoinRequestHandler }, store.ErrNotFound, http.StatusNotFound, store.ModActionDenyJoin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			requests := &mockJoinRequestStore{err: tt.err}
+			app.store.JoinRequests = requests
+
+			community := &store.CommunityDetails{}
+			community.ID = 1
+			user := &store.UserDetails{}
+			user.ID = 3
+
+			req := newCommunityRequest(http.MethodPost, community, user, nil)
+			req = withURLParams(req, "id", "7")
+			rr := httptest.NewRecorder()
+
+			tt.handler(app)(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			entry := requests.entry
+			if entry == nil || entry.Action != tt.action || *entry.TargetID != 7 || *entry.CommunityID != 1 || *entry.ActorID != 3 {
+				t.Errorf("expected a %s entry for the request, got %+v", tt.action, entry)
+			}
+		})
+	}
+
+	t.Run("should reject an invalid id", func(t *testing.T) {
+		app := newTestApplication(t)
+		requests := &mockJoinRequestStore{}
+		app.store.JoinRequests = requests
+
+		req := newCommunityRequest(http.MethodPost, &store.CommunityDetails{}, &store.UserDetails{}, nil)
+		req = withURLParams(req, "id", "abc")
+		rr := httptest.NewRecorder()
+
+		app.approveJoinRequestHandler(rr, req)
+
+		checResponseCode(t, http.StatusBadRequest, rr.Code)
+
+		if requests.entry != nil {
+			t.Error("expected the request to be left alone")
+		}
+	})
+}
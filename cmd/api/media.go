@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+	"github.com/skiba-mateusz/communiverse/internal/uploader"
+)
+
+const (
+	maxPostMedia = 20
+	// maxImagePixels caps the size of a decoded image, a small compressed file
+	// can otherwise claim dimensions that take gigabytes to decode.
+	maxImagePixels = 40_000_000
+)
+
+var (
+	errNoImages      = fmt.Errorf("at least one image is required")
+	errInvalidImage  = fmt.Errorf("images must be valid JPEG, PNG or GIF files")
+	errImageTooLarge = fmt.Errorf("images can be at most %d megapixels", maxImagePixels/1_000_000)
+)
+
+// postMediaSizes are the sizes every post image is uploaded in, they share the
+// file id and differ by folder.
+var postMediaSizes = []struct {
+	folder string
+	width  int
+	height int
+	fit    bool
+}{
+	{"post-media", 1920, 1920, true},
+	{"post-media/previews", 640, 640, true},
+	{"post-media/thumbnails", 160, 160, false},
+}
+
+type PostMediaPayload struct {
+	Caption string `json:"caption" validate:"max=300"`
+	AltText string `json:"altText" validate:"max=300"`
+}
+
+type UpdatePostMediaPayload struct {
+	Caption *string `json:"caption" validate:"omitempty,max=300"`
+	AltText *string `json:"altText" validate:"omitempty,max=300"`
+}
+
+type ReorderPostMediaPayload struct {
+	IDs []int64 `json:"ids" validate:"required,min=1,max=20,dive,min=1"`
+}
+
+func (app *application) getPostMediaHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromContext(r)
+
+	media := []store.PostMedia{}
+
+	if post.Tombstone == "" {
+		var err error
+
+		media, err = app.store.Media.GetPostMedia(r.Context(), post.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	app.setMediaURLs(media)
+
+	if err := jsonResponse(w, http.StatusOK, media); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// uploadPostMediaHandler appends the uploaded images to the post gallery, each
+// image can be given a caption and alt text by repeating the fields in the
+// same order as the images.
+func (app *application) uploadPostMediaHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post := getPostFromContext(r)
+	if post.Tombstone != "" {
+		app.specificForbiddenResponse(w, r, errPostDeleted)
+		return
+	}
+
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		app.badRequestResponse(w, r, errNoImages)
+		return
+	}
+
+	if len(files) > maxPostMedia {
+		app.conflictResponse(w, r, store.ErrMediaLimit)
+		return
+	}
+
+	captions := r.MultipartForm.Value["caption"]
+	altTexts := r.MultipartForm.Value["altText"]
+
+	payloads := make([]PostMediaPayload, len(files))
+	for i := range payloads {
+		if i < len(captions) {
+			payloads[i].Caption = captions[i]
+		}
+		if i < len(altTexts) {
+			payloads[i].AltText = altTexts[i]
+		}
+
+		if err := Validate.Struct(payloads[i]); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	// Dimensions are checked up front from the headers, so an image that
+	// would be too large to decode is rejected before anything is uploaded.
+	for _, header := range files {
+		if err := checkImageSize(header); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	media := make([]store.PostMedia, 0, len(files))
+
+	// Images are decoded one at a time so only one is held in memory.
+	for i, header := range files {
+		img, err := decodeImage(header)
+		if err != nil {
+			app.deleteMediaFiles(ctx, uploadedFileIDs(media))
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		fileID, err := app.uploadPostImage(ctx, img)
+		if err != nil {
+			app.deleteMediaFiles(ctx, uploadedFileIDs(media))
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		media = append(media, store.PostMedia{
+			FileID:  fileID,
+			Caption: payloads[i].Caption,
+			AltText: payloads[i].AltText,
+			Width:   img.Bounds().Dx(),
+			Height:  img.Bounds().Dy(),
+		})
+	}
+
+	if err := app.store.Media.Create(ctx, post.ID, media, maxPostMedia); err != nil {
+		app.deleteMediaFiles(ctx, uploadedFileIDs(media))
+
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrMediaLimit:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.setMediaURLs(media)
+
+	if err := jsonResponse(w, http.StatusCreated, media); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) updatePostMediaHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdatePostMediaPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "mediaID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post := getPostFromContext(r)
+	if post.Tombstone != "" {
+		app.specificForbiddenResponse(w, r, errPostDeleted)
+		return
+	}
+
+	ctx := r.Context()
+
+	media, err := app.store.Media.GetByID(ctx, id, post.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.Caption != nil {
+		media.Caption = *payload.Caption
+	}
+	if payload.AltText != nil {
+		media.AltText = *payload.AltText
+	}
+
+	if err = app.store.Media.Update(ctx, media); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.setMediaURL(media)
+
+	if err = jsonResponse(w, http.StatusOK, media); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) reorderPostMediaHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ReorderPostMediaPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post := getPostFromContext(r)
+	if post.Tombstone != "" {
+		app.specificForbiddenResponse(w, r, errPostDeleted)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := app.store.Media.Reorder(ctx, post.ID, payload.IDs); err != nil {
+		switch err {
+		case store.ErrMediaOrderMismatch:
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	media, err := app.store.Media.GetPostMedia(ctx, post.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.setMediaURLs(media)
+
+	if err = jsonResponse(w, http.StatusOK, media); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deletePostMediaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "mediaID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post := getPostFromContext(r)
+	if post.Tombstone != "" {
+		app.specificForbiddenResponse(w, r, errPostDeleted)
+		return
+	}
+
+	ctx := r.Context()
+
+	fileID, err := app.store.Media.Delete(ctx, id, post.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.deleteMediaFiles(ctx, []string{fileID})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkImageSize reads the dimensions of an uploaded image without decoding it.
+func checkImageSize(header *multipart.FileHeader) error {
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return errInvalidImage
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		return errImageTooLarge
+	}
+
+	return nil
+}
+
+func decodeImage(header *multipart.FileHeader) (image.Image, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, errInvalidImage
+	}
+
+	return img, nil
+}
+
+// uploadPostImage uploads every size of the image under a single file id.
+func (app *application) uploadPostImage(ctx context.Context, img image.Image) (string, error) {
+	fileID := uuid.New().String()
+
+	for i, size := range postMediaSizes {
+		_, _, err := app.uploader.ProcessAndUploadImage(ctx, img, uploader.UploadImageOptions{
+			Width:    size.width,
+			Height:   size.height,
+			Quality:  90,
+			MimeType: "image/jpeg",
+			Folder:   size.folder,
+			Fit:      size.fit,
+			ID:       fileID,
+		})
+		if err != nil {
+			for _, uploaded := range postMediaSizes[:i] {
+				app.deleteMediaFile(ctx, uploaded.folder, fileID)
+			}
+			return "", err
+		}
+	}
+
+	return fileID, nil
+}
+
+// deleteMediaFiles deletes every size of the given files from storage. It runs
+// once the rows are gone so failures are only logged, leaving orphaned files
+// rather than broken images.
+func (app *application) deleteMediaFiles(ctx context.Context, fileIDs []string) {
+	for _, fileID := range fileIDs {
+		for _, size := range postMediaSizes {
+			app.deleteMediaFile(ctx, size.folder, fileID)
+		}
+	}
+}
+
+func (app *application) deleteMediaFile(ctx context.Context, folder, fileID string) {
+	key := fmt.Sprintf("%s/%s", folder, fileID)
+
+	if err := app.uploader.DeleteFile(ctx, key); err != nil {
+		app.logger.Errorw("error deleting media file", "key", key, "error", err)
+	}
+}
+
+func (app *application) setMediaURLs(media []store.PostMedia) {
+	for i := range media {
+		app.setMediaURL(&media[i])
+	}
+}
+
+func (app *application) setMediaURL(media *store.PostMedia) {
+	media.URL = app.generateAssetURL(media.FileID, postMediaSizes[0].folder)
+	media.PreviewURL = app.generateAssetURL(media.FileID, postMediaSizes[1].folder)
+	media.ThumbnailURL = app.generateAssetURL(media.FileID, postMediaSizes[2].folder)
+}
+
+func uploadedFileIDs(media []store.PostMedia) []string {
+	fileIDs := make([]string, len(media))
+	for i := range media {
+		fileIDs[i] = media[i].FileID
+	}
+
+	return fileIDs
+}
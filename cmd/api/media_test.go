@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockMediaStore struct {
+	store.MediaStore
+	reorderErr error
+	created    bool
+}
+
+func (m *mockMediaStore) Create(ctx context.Context, postID int64, media []store.PostMedia, limit int) error {
+	m.created = true
+	return nil
+}
+
+func (m *mockMediaStore) Reorder(ctx context.Context, postID int64, ids []int64) error {
+	return m.reorderErr
+}
+
+func (m *mockMediaStore) GetPostMedia(ctx context.Context, postID int64) ([]store.PostMedia, error) {
+	return []store.PostMedia{}, nil
+}
+
+func newMediaRequest(method, contentType string, body *bytes.Buffer, post *store.PostDetails) *http.Request {
+	req := httptest.NewRequest(method, "/", body)
+	req.Header.Set("Content-Type", contentType)
+
+	ctx := context.WithValue(req.Context(), postCtx, post)
+	ctx = context.WithValue(ctx, userCtx, &store.UserDetails{})
+
+	return req.WithContext(ctx)
+}
+
+func TestUploadPostMedia(t *testing.T) {
+	t.Run("should reject more images than a gallery holds", func(t *testing.T) {
+		app := newTestApplication(t)
+		media := &mockMediaStore{}
+		app.store.Media = media
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for i := 0; i <= maxPostMedia; i++ {
+			part, err := form.CreateFormFile("images", fmt.Sprintf("%d.png", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte("image"))
+		}
+		form.Close()
+
+		req := newMediaRequest(http.MethodPost, form.FormDataContentType(), &body, &store.PostDetails{})
+		rr := httptest.NewRecorder()
+
+		app.uploadPostMediaHandler(rr, req)
+
+		checResponseCode(t, http.StatusConflict, rr.Code)
+
+		if media.created {
+			t.Error("expected no images to be added")
+		}
+	})
+
+	t.Run("should reject images over the pixel cap", func(t *testing.T) {
+		app := newTestApplication(t)
+		media := &mockMediaStore{}
+		app.store.Media = media
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("images", "huge.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(pngHeader(t, 20000, 20000))
+		form.Close()
+
+		req := newMediaRequest(http.MethodPost, form.FormDataContentType(), &body, &store.PostDetails{})
+		rr := httptest.NewRecorder()
+
+		app.uploadPostMediaHandler(rr, req)
+
+		checResponseCode(t, http.StatusBadRequest, rr.Code)
+
+		if !strings.Contains(rr.Body.String(), errImageTooLarge.Error()) {
+			t.Errorf("expected %q, got %s", errImageTooLarge, rr.Body.String())
+		}
+
+		if media.created {
+			t.Error("expected no images to be added")
+		}
+	})
+}
+
+// pngHeader encodes a one pixel PNG and rewrites its header to claim the given
+// dimensions, which is all a size check reads.
+func pngHeader(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	// The IHDR chunk follows the 8 byte signature: length, type, data, crc.
+	ihdr := data[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(data[12+4+13:], crc32.ChecksumIEEE(ihdr))
+
+	return data
+}
+
+func TestReorderPostMedia(t *testing.T) {
+	t.Run("should reject an order that doesn't match the gallery", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.store.Media = &mockMediaStore{reorderErr: store.ErrMediaOrderMismatch}
+
+		body := bytes.NewBufferString(`{"ids": [2, 1]}`)
+		req := newMediaRequest(http.MethodPut, "application/json", body, &store.PostDetails{})
+		rr := httptest.NewRecorder()
+
+		app.reorderPostMediaHandler(rr, req)
+
+		checResponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should reject reordering a deleted post", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.store.Media = &mockMediaStore{}
+
+		post := &store.PostDetails{}
+		post.Tombstone = store.TombstoneDeleted
+
+		body := bytes.NewBufferString(`{"ids": [1, 2]}`)
+		req := newMediaRequest(http.MethodPut, "application/json", body, post)
+		rr := httptest.NewRecorder()
+
+		app.reorderPostMediaHandler(rr, req)
+
+		checResponseCode(t, http.StatusForbidden, rr.Code)
+
+		if !strings.Contains(rr.Body.String(), errPostDeleted.Error()) {
+			t.Errorf("expected deleted post error, got %s", rr.Body.String())
+		}
+	})
+}
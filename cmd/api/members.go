@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var (
+	errManageCreator = fmt.Errorf("the community creator can't be changed or removed")
+	errManageSelf    = fmt.Errorf("you can't change or remove your own membership")
+	errManageHigher  = fmt.Errorf("you can only manage members below your own role")
+	errPromoteHigher = fmt.Errorf("you can't promote members above your own role")
+)
+
+func (app *application) getCommunityMembersHandler(w http.ResponseWriter, r *http.Request) {
+	query := store.PaginatedMembersQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	query, err := query.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	members, err := app.store.Members.GetCommunityMembers(r.Context(), community.ID, query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range members {
+		members[i].User.AvatarURL = app.generateAssetURL(members[i].User.AvatarID, "avatars")
+	}
+
+	if err = jsonResponse(w, http.StatusOK, members); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type UpdateMemberRolePayload struct {
+	Role string `json:"role" validate:"required,oneof=member moderator admin"`
+}
+
+func (app *application) updateMemberRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateMemberRolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	member, ok := app.getManageableMember(w, r)
+	if !ok {
+		return
+	}
+
+	role, err := app.store.Roles.GetByName(ctx, payload.Role)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if role.Level > app.communityRoleLevel(r) {
+		app.specificForbiddenResponse(w, r, errPromoteHigher)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionUpdateMemberRole,
+		TargetType:  "user",
+		TargetID:    &member.User.ID,
+		TargetLabel: member.User.Username,
+		Reason:      fmt.Sprintf("%s -> %s", member.Role.Name, role.Name),
+	})
+
+	if err = app.store.Members.UpdateRole(ctx, community.ID, member.User.ID, role.Name, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	member.Role = *role
+	member.User.AvatarURL = app.generateAssetURL(member.User.AvatarID, "avatars")
+
+	if err = jsonResponse(w, http.StatusOK, member); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) removeMemberHandler(w http.ResponseWriter, r *http.Request) {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	member, ok := app.getManageableMember(w, r)
+	if !ok {
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionRemoveMember,
+		TargetType:  "user",
+		TargetID:    &member.User.ID,
+		TargetLabel: member.User.Username,
+		Reason:      reason,
+	})
+
+	if err = app.store.Members.Remove(r.Context(), community.ID, member.User.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getManageableMember loads the member named in the URL and makes sure the
+// current user outranks them within the community.
+func (app *application) getManageableMember(w http.ResponseWriter, r *http.Request) (*store.Member, bool) {
+	username := chi.URLParam(r, "username")
+	community := getCommunityFromContext(r)
+
+	member, err := app.store.Members.GetByUsername(r.Context(), community.ID, username)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if err = app.checkCanManage(r, member.User.ID, member.Role.Level); err != nil {
+		app.specificForbiddenResponse(w, r, err)
+		return nil, false
+	}
+
+	return member, true
+}
+
+func (app *application) checkCanManage(r *http.Request, targetID int64, targetLevel int) error {
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	switch {
+	case targetID == community.UserID:
+		return errManageCreator
+	case targetID == user.ID:
+		return errManageSelf
+	case targetLevel >= app.communityRoleLevel(r):
+		return errManageHigher
+	}
+
+	return nil
+}
+
+// communityRoleLevel is the highest of the user's community and global role
+// levels, the community creator outranks everyone.
+func (app *application) communityRoleLevel(r *http.Request) int {
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	if community.UserID == user.ID {
+		return math.MaxInt
+	}
+
+	return max(community.Role.Level, user.Role.Level)
+}
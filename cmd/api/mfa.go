@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/auth"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var (
+	errMFARequired     = fmt.Errorf("two-factor authentication must be enabled for this action")
+	numRecoveryCodes   = 10
+	recoveryCodeLength = 5
+)
+
+type EnrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func (app *application) enrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	if user.MFAEnabled {
+		app.badRequestResponse(w, r, fmt.Errorf("two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = app.store.Users.SetTOTPSecret(r.Context(), user.ID, secret); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, fmt.Errorf("two-factor authentication is already enabled"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	response := EnrollTOTPResponse{
+		Secret: secret,
+		URI:    auth.TOTPURI(secret, app.config.auth.token.iss, user.Email),
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type TOTPCodePayload struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (app *application) confirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TOTPCodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	if user.MFAEnabled {
+		app.badRequestResponse(w, r, fmt.Errorf("two-factor authentication is already enabled"))
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("two-factor authentication enrollment not started"))
+		return
+	}
+
+	valid, err := app.useTOTPCode(r.Context(), user, payload.Code)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !valid {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid authentication code"))
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = app.store.Users.EnableTOTP(r.Context(), user.ID, hashes); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) disableTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TOTPCodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	if !user.MFAEnabled {
+		app.badRequestResponse(w, r, fmt.Errorf("two-factor authentication is not enabled"))
+		return
+	}
+
+	roles := []store.Role{user.Role}
+
+	communityRole, err := app.store.Roles.GetHighestCommunityRole(ctx, user.ID)
+	if err != nil && err != store.ErrNotFound {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if communityRole != nil {
+		roles = append(roles, *communityRole)
+	}
+
+	privileged, err := app.isPrivileged(ctx, roles...)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if privileged {
+		app.specificForbiddenResponse(w, r, fmt.Errorf("two-factor authentication is required for moderators, admins and staff"))
+		return
+	}
+
+	valid, err := app.useTOTPCode(ctx, user, payload.Code)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !valid {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid authentication code"))
+		return
+	}
+
+	if err = app.store.Users.DisableTOTP(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// useTOTPCode accepts a code only once: its time step has to be newer than
+// the last one the user signed in with.
+func (app *application) useTOTPCode(ctx context.Context, user *store.UserDetails, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	if err := app.store.Users.UseTOTPStep(ctx, user.ID, step); err != nil {
+		if err == store.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, numRecoveryCodes)
+	hashes := make([]string, 0, numRecoveryCodes)
+
+	for i := 0; i < numRecoveryCodes; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(b)
+		hash := sha256.Sum256([]byte(code))
+
+		codes = append(codes, code)
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+
+	return codes, hashes, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/auth"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type stepUserStore struct {
+	store.MockUserStore
+	lastStep int64
+}
+
+func (m *stepUserStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	if step <= m.lastStep {
+		return store.ErrNotFound
+	}
+	m.lastStep = step
+	return nil
+}
+
+func TestUseTOTPCode(t *testing.T) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := auth.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApplication(t)
+	app.store.Users = &stepUserStore{}
+
+	user := &store.UserDetails{TOTPSecret: secret}
+
+	valid, err := app.useTOTPCode(context.Background(), user, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !valid {
+		t.Fatal("expected the code to be accepted")
+	}
+
+	valid, err = app.useTOTPCode(context.Background(), user, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if valid {
+		t.Error("expected a replayed code to be rejected")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/skiba-mateusz/communiverse/internal/store"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -27,6 +28,12 @@ func (app *application) tokenAuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		token := parts[1]
+
+		if strings.HasPrefix(token, personalAccessTokenPrefix) {
+			app.personalAccessTokenAuth(w, r, next, token)
+			return
+		}
+
 		jwtToken, err := app.authenticator.ValidateToken(token)
 		if err != nil {
 			app.unauthorizedResponse(w, r, err)
@@ -35,26 +42,101 @@ func (app *application) tokenAuthMiddleware(next http.Handler) http.Handler {
 
 		claims, _ := jwtToken.Claims.(jwt.MapClaims)
 
+		if claims["typ"] != accessTokenType {
+			app.unauthorizedResponse(w, r, fmt.Errorf("invalid token type"))
+			return
+		}
+
 		userID, err := strconv.ParseInt(fmt.Sprintf("%.f", claims["sub"]), 10, 64)
 		if err != nil {
 			app.unauthorizedResponse(w, r, err)
 			return
 		}
 
+		sessionID, err := strconv.ParseInt(fmt.Sprintf("%.f", claims["sid"]), 10, 64)
+		if err != nil {
+			app.unauthorizedResponse(w, r, err)
+			return
+		}
+
 		ctx := r.Context()
 
+		session, err := app.store.Sessions.GetByID(ctx, sessionID)
+		if err != nil {
+			app.unauthorizedResponse(w, r, err)
+			return
+		}
+
+		if session.UserID != userID {
+			app.unauthorizedResponse(w, r, fmt.Errorf("session does not belong to user"))
+			return
+		}
+
 		user, err := app.store.Users.GetByID(ctx, userID)
 		if err != nil {
 			app.unauthorizedResponse(w, r, err)
 			return
 		}
 
+		if err = app.store.Sessions.Touch(ctx, session.ID, getClientIP(r)); err != nil {
+			app.logger.Errorw("error updating session activity", "error", err)
+		}
+
 		ctx = context.WithValue(ctx, userCtx, user)
+		ctx = context.WithValue(ctx, sessionCtx, session)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (app *application) personalAccessTokenAuth(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	ctx := r.Context()
+
+	accessToken, err := app.store.Tokens.GetByToken(ctx, token)
+	if err != nil {
+		app.unauthorizedResponse(w, r, err)
+		return
+	}
+
+	scope, ok := requiredTokenScope(r)
+	if !ok || !slices.Contains(accessToken.Scopes, scope) {
+		app.specificForbiddenResponse(w, r, fmt.Errorf("access token is missing required scope"))
+		return
+	}
+
+	user, err := app.store.Users.GetByID(ctx, accessToken.UserID)
+	if err != nil {
+		app.unauthorizedResponse(w, r, err)
+		return
+	}
+
+	if err = app.store.Tokens.Touch(ctx, accessToken.ID); err != nil {
+		app.logger.Errorw("error updating access token activity", "error", err)
+	}
+
+	ctx = context.WithValue(ctx, userCtx, user)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (app *application) requireSessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasSession(r) {
+			app.specificForbiddenResponse(w, r, fmt.Errorf("this action requires a login session"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasSession reports whether the request was signed in with a login session
+// rather than a personal access token.
+func hasSession(r *http.Request) bool {
+	_, ok := r.Context().Value(sessionCtx).(*store.Session)
+	return ok
+}
+
 func (app *application) basicAuthMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -138,6 +220,31 @@ func (app *application) authorizeWithOwnership(requiredRole, resourceType string
 		hasGlobalRole = allowed
 
 		if isOwner || hasCommunityRole || hasGlobalRole {
+			moderating := !isOwner || resourceType == "community"
+
+			// Access tokens act for their owner as an author only, whatever
+			// roles the owner holds.
+			if moderating && !hasSession(r) {
+				app.specificForbiddenResponse(w, r, fmt.Errorf("access tokens can only change their owner's content"))
+				return
+			}
+
+			// Moderating someone else's content, or running a community as its
+			// creator, needs MFA. Authors acting on their own content and member
+			// level actions don't.
+			if moderating && !user.MFAEnabled {
+				privileged, err := app.isPrivilegedRole(ctx, requiredRole)
+				if err != nil {
+					app.internalServerError(w, r, err)
+					return
+				}
+
+				if privileged {
+					app.specificForbiddenResponse(w, r, errMFARequired)
+					return
+				}
+			}
+
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -146,6 +253,56 @@ func (app *application) authorizeWithOwnership(requiredRole, resourceType string
 	})
 }
 
+func (app *application) authorizeWithRole(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+
+		allowed, err := app.checkRole(r.Context(), user.Role, requiredRole)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		if !user.MFAEnabled {
+			app.specificForbiddenResponse(w, r, errMFARequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isPrivileged reports whether any of the roles can moderate.
+func (app *application) isPrivileged(ctx context.Context, roles ...store.Role) (bool, error) {
+	for _, role := range roles {
+		allowed, err := app.checkRole(ctx, role, "moderator")
+		if err != nil {
+			return false, err
+		}
+
+		if allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isPrivilegedRole reports whether roleName is at least moderator.
+func (app *application) isPrivilegedRole(ctx context.Context, roleName string) (bool, error) {
+	role, err := app.store.Roles.GetByName(ctx, roleName)
+	if err != nil {
+		return false, err
+	}
+
+	return app.isPrivileged(ctx, *role)
+}
+
 func (app *application) checkRole(ctx context.Context, role store.Role, roleName string) (bool, error) {
 	r, err := app.store.Roles.GetByName(ctx, roleName)
 	if err != nil {
@@ -154,3 +311,36 @@ func (app *application) checkRole(ctx context.Context, role store.Role, roleName
 
 	return role.Level >= r.Level, nil
 }
+
+// hasCommunityRole reports whether the current user holds at least the given
+// role in the community, either through their community role or a global one.
+// The community creator holds every role.
+func (app *application) hasCommunityRole(r *http.Request, roleName string) (bool, error) {
+	return app.holdsCommunityRole(r.Context(), getCommunityFromContext(r), getUserFromContext(r), roleName)
+}
+
+// holdsCommunityRole is hasCommunityRole for callers outside a request, such
+// as background jobs acting on behalf of a user.
+func (app *application) holdsCommunityRole(ctx context.Context, community *store.CommunityDetails, user *store.UserDetails, roleName string) (bool, error) {
+	if community.UserID == user.ID {
+		return true, nil
+	}
+
+	roles := []store.Role{user.Role}
+	if community.Role.ID != -1 {
+		roles = append(roles, community.Role)
+	}
+
+	for _, role := range roles {
+		allowed, err := app.checkRole(ctx, role, roleName)
+		if err != nil {
+			return false, err
+		}
+
+		if allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var testRoles = map[string]store.Role{
+	"user":      {ID: 1, Name: "user", Level: 1},
+	"member":    {ID: 2, Name: "member", Level: 2},
+	"moderator": {ID: 3, Name: "moderator", Level: 3},
+	"admin":     {ID: 4, Name: "admin", Level: 4},
+	"staff":     {ID: 5, Name: "staff", Level: 5},
+}
+
+// noCommunityRole is what the community store returns for non-members.
+var noCommunityRole = store.Role{ID: -1}
+
+type mockRoleStore struct {
+	store.RoleStore
+}
+
+func (m *mockRoleStore) GetByName(ctx context.Context, name string) (*store.Role, error) {
+	role, ok := testRoles[name]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &role, nil
+}
+
+func (m *mockRoleStore) GetHighestCommunityRole(ctx context.Context, userID int64) (*store.Role, error) {
+	return nil, store.ErrNotFound
+}
+
+func newTestApplicationWithRoles(t *testing.T) *application {
+	t.Helper()
+
+	app := newTestApplication(t)
+	app.store.Roles = &mockRoleStore{}
+
+	return app
+}
+
+// newCommunityRequest builds a request as the community middleware would
+// leave it: the community, the signed in user and optionally a post.
+func newCommunityRequest(method string, community *store.CommunityDetails, user *store.UserDetails, post *store.PostDetails) *http.Request {
+	req := httptest.NewRequest(method, "/", nil)
+
+	ctx := context.WithValue(req.Context(), communityCtx, community)
+	ctx = context.WithValue(ctx, userCtx, user)
+	if post != nil {
+		ctx = context.WithValue(ctx, postCtx, post)
+	}
+
+	return req.WithContext(ctx)
+}
+
+// withSession marks the request as signed in with a login session rather
+// than a personal access token.
+func withSession(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), sessionCtx, &store.Session{}))
+}
+
+// withURLParams sets route parameters the way chi would, as key value pairs.
+func withURLParams(req *http.Request, params ...string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// withBody sets the JSON request body.
+func withBody(req *http.Request, body string) *http.Request {
+	req.Body = io.NopCloser(strings.NewReader(body))
+	return req
+}
+
+func TestAuthorizeMFA(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	newUser := func(id int64, role string, mfa bool) *store.UserDetails {
+		user := &store.UserDetails{Role: testRoles[role], MFAEnabled: mfa}
+		user.ID = id
+		return user
+	}
+
+	t.Run("authorizeWithOwnership", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			resource      string
+			user          *store.UserDetails
+			communityRole store.Role
+			expected      int
+		}{
+			{"author without mfa", "post", newUser(2, "user", false), testRoles["member"], http.StatusOK},
+			{"author with mfa", "post", newUser(2, "user", true), testRoles["member"], http.StatusOK},
+			{"creator without mfa", "community", newUser(1, "user", false), testRoles["member"], http.StatusForbidden},
+			{"creator with mfa", "community", newUser(1, "user", true), testRoles["member"], http.StatusOK},
+			{"moderator without mfa", "post", newUser(3, "user", false), testRoles["moderator"], http.StatusForbidden},
+			{"moderator with mfa", "post", newUser(3, "user", true), testRoles["moderator"], http.StatusOK},
+			{"admin without mfa", "post", newUser(3, "admin", false), noCommunityRole, http.StatusForbidden},
+			{"admin with mfa", "post", newUser(3, "admin", true), noCommunityRole, http.StatusOK},
+			{"staff without mfa", "post", newUser(3, "staff", false), noCommunityRole, http.StatusForbidden},
+			{"staff with mfa", "post", newUser(3, "staff", true), noCommunityRole, http.StatusOK},
+			{"member with mfa", "post", newUser(3, "user", true), testRoles["member"], http.StatusForbidden},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				app := newTestApplicationWithRoles(t)
+
+				community := &store.CommunityDetails{}
+				community.UserID = 1
+				community.Role = tt.communityRole
+				post := &store.PostDetails{}
+				post.UserID = 2
+
+				req := withSession(newCommunityRequest(http.MethodDelete, community, tt.user, post))
+				rr := httptest.NewRecorder()
+
+				app.authorizeWithOwnership("moderator", tt.resource, ok)(rr, req)
+
+				checResponseCode(t, tt.expected, rr.Code)
+			})
+		}
+	})
+
+	t.Run("authorizeWithRole", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			user     *store.UserDetails
+			expected int
+		}{
+			{"user with mfa", newUser(1, "user", true), http.StatusForbidden},
+			{"moderator without mfa", newUser(1, "moderator", false), http.StatusForbidden},
+			{"moderator with mfa", newUser(1, "moderator", true), http.StatusOK},
+			{"admin without mfa", newUser(1, "admin", false), http.StatusForbidden},
+			{"admin with mfa", newUser(1, "admin", true), http.StatusOK},
+			{"staff without mfa", newUser(1, "staff", false), http.StatusForbidden},
+			{"staff with mfa", newUser(1, "staff", true), http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				app := newTestApplicationWithRoles(t)
+
+				req := withSession(newCommunityRequest(http.MethodPost, &store.CommunityDetails{}, tt.user, nil))
+				rr := httptest.NewRecorder()
+
+				app.authorizeWithRole("moderator", ok)(rr, req)
+
+				checResponseCode(t, tt.expected, rr.Code)
+			})
+		}
+	})
+}
+
+func TestAuthorizeAccessToken(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name          string
+		userID        int64
+		role          string
+		communityRole store.Role
+		expected      int
+	}{
+		{"author", 2, "user", testRoles["member"], http.StatusOK},
+		{"moderator", 3, "user", testRoles["moderator"], http.StatusForbidden},
+		{"admin", 3, "admin", noCommunityRole, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplicationWithRoles(t)
+
+			community := &store.CommunityDetails{}
+			community.UserID = 1
+			community.Role = tt.communityRole
+			post := &store.PostDetails{}
+			post.UserID = 2
+			user := &store.UserDetails{Role: testRoles[tt.role], MFAEnabled: true}
+			user.ID = tt.userID
+
+			req := newCommunityRequest(http.MethodDelete, community, user, post)
+			rr := httptest.NewRecorder()
+
+			app.authorizeWithOwnership("moderator", "post", ok)(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type PaginatedModLogResponse struct {
+	Items []store.ModLogEntry `json:"items"`
+	Meta  store.Meta          `json:"meta"`
+}
+
+func (app *application) getModLogHandler(w http.ResponseWriter, r *http.Request) {
+	query := store.PaginatedModLogQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	query, err := query.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	entries, meta, err := app.store.ModLog.GetCommunityLog(r.Context(), community.ID, query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range entries {
+		entries[i].Actor.AvatarURL = app.generateAssetURL(entries[i].Actor.AvatarID, "avatars")
+	}
+
+	response := PaginatedModLogResponse{
+		Items: entries,
+		Meta:  meta,
+	}
+
+	if err = jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// authorizeModLog lets anyone read the mod log of a community that made it
+// public, otherwise only community admins can.
+func (app *application) authorizeModLog(next http.HandlerFunc) http.HandlerFunc {
+	restricted := app.authorizeWithOwnership("admin", "community", next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		community := getCommunityFromContext(r)
+		if community.PublicModLog {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		restricted.ServeHTTP(w, r)
+	})
+}
+
+// modLogEntry attributes a moderation action to the current user, for store
+// methods that write the entry in the same transaction as the action.
+func modLogEntry(r *http.Request, entry store.ModLogEntry) *store.ModLogEntry {
+	user := getUserFromContext(r)
+	entry.ActorID = &user.ID
+
+	return &entry
+}
+
+// modReason is the optional reason given for a moderation action that has no
+// request body, such as a deletion.
+func modReason(r *http.Request) (string, error) {
+	reason := r.URL.Query().Get("reason")
+	if err := Validate.Var(reason, "max=255"); err != nil {
+		return "", err
+	}
+
+	return reason, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type PaginatedQueueResponse struct {
+	Items []store.QueueItem `json:"items"`
+	Meta  store.Meta        `json:"meta"`
+}
+
+func (app *application) getModQueueHandler(w http.ResponseWriter, r *http.Request) {
+	query := store.PaginatedQueueQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	query, err := query.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	items, meta, err := app.store.ModQueue.GetQueue(r.Context(), queueScope(r), query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range items {
+		items[i].Community.ThumbnailURL = app.generateAssetURL(items[i].Community.ThumbnailID, "thumbnails")
+		items[i].User.AvatarURL = app.generateAssetURL(items[i].User.AvatarID, "avatars")
+	}
+
+	response := PaginatedQueueResponse{
+		Items: items,
+		Meta:  meta,
+	}
+
+	if err = jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) approveQueueItemHandler(w http.ResponseWriter, r *http.Request) {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	item, err := app.getQueueItem(r)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	entry := modLogEntry(r, queueLogEntry(item, false, reason))
+
+	if err = app.store.ModQueue.Approve(r.Context(), item.TargetType, item.TargetID, user.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) removeQueueItemHandler(w http.ResponseWriter, r *http.Request) {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	item, err := app.getQueueItem(r)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	entry := modLogEntry(r, queueLogEntry(item, true, reason))
+
+	if err = app.store.ModQueue.Remove(r.Context(), item.TargetType, item.TargetID, &user.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreQueueItemHandler brings back content deleted by its author or removed
+// by a moderator, as long as it hasn't been purged yet.
+func (app *application) restoreQueueItemHandler(w http.ResponseWriter, r *http.Request) {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	item, err := app.getQueueItem(r)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	entry := store.ModLogEntry{
+		CommunityID: &item.Community.ID,
+		Action:      store.ModActionRestorePost,
+		TargetType:  item.TargetType,
+		TargetID:    &item.TargetID,
+		TargetLabel: item.PostTitle,
+		Reason:      reason,
+	}
+
+	if item.TargetType == "comment" {
+		entry.Action = store.ModActionRestoreComment
+		entry.TargetLabel = item.User.Username
+	}
+
+	if err = app.store.Tombstones.Restore(r.Context(), item.TargetType, item.TargetID, modLogEntry(r, entry)); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type RemoveAndBanPayload struct {
+	Reason        string `json:"reason" validate:"max=255"`
+	DurationHours *int   `json:"durationHours" validate:"omitempty,min=1,max=8760"`
+	Notify        bool   `json:"notify"`
+}
+
+func (app *application) removeAndBanQueueItemHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RemoveAndBanPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	item, err := app.getQueueItem(r)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	ctx := r.Context()
+	user := getUserFromContext(r)
+
+	if queueScope(r) != nil {
+		targetLevel := 0
+		member, err := app.store.Members.GetByUsername(ctx, item.Community.ID, item.User.Username)
+		switch err {
+		case nil:
+			targetLevel = member.Role.Level
+		case store.ErrNotFound:
+		default:
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if err = app.checkCanManage(r, item.User.ID, targetLevel); err != nil {
+			app.specificForbiddenResponse(w, r, err)
+			return
+		}
+	} else if item.User.ID == user.ID {
+		app.specificForbiddenResponse(w, r, errManageSelf)
+		return
+	}
+
+	var exp *time.Duration
+	if payload.DurationHours != nil {
+		d := time.Hour * time.Duration(*payload.DurationHours)
+		exp = &d
+	}
+
+	ban := &store.Ban{
+		CommunityID: item.Community.ID,
+		User:        item.User,
+		ModeratorID: &user.ID,
+		Type:        store.BanTypeBan,
+		Reason:      payload.Reason,
+	}
+
+	removeEntry := modLogEntry(r, queueLogEntry(item, true, payload.Reason))
+	banEntry := modLogEntry(r, banLogEntry(ban))
+
+	if err = app.store.ModQueue.RemoveAndBan(ctx, item.TargetType, item.TargetID, ban, exp, removeEntry, banEntry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.Notify {
+		app.sendBanEmail(r, item.Community.Name, ban)
+	}
+
+	ban.User.AvatarURL = app.generateAssetURL(ban.User.AvatarID, "avatars")
+
+	if err = jsonResponse(w, http.StatusCreated, ban); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getQueueItem loads the post or comment named in the URL, content outside
+// the community being moderated is reported as not found.
+func (app *application) getQueueItem(r *http.Request) (*store.QueueItem, error) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "targetID"), 10, 64)
+	if err != nil {
+		return nil, store.ErrNotFound
+	}
+
+	item, err := app.store.ModQueue.GetItem(r.Context(), chi.URLParam(r, "targetType"), targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if communityID := queueScope(r); communityID != nil && *communityID != item.Community.ID {
+		return nil, store.ErrNotFound
+	}
+
+	return item, nil
+}
+
+// queueLogEntry describes approving or removing the queue item.
+func queueLogEntry(item *store.QueueItem, removed bool, reason string) store.ModLogEntry {
+	entry := store.ModLogEntry{
+		CommunityID: &item.Community.ID,
+		TargetType:  item.TargetType,
+		TargetID:    &item.TargetID,
+		TargetLabel: item.PostTitle,
+		Reason:      reason,
+	}
+
+	switch {
+	case item.TargetType == "post" && removed:
+		entry.Action = store.ModActionRemovePost
+	case item.TargetType == "post":
+		entry.Action = store.ModActionApprovePost
+	case removed:
+		entry.Action = store.ModActionRemoveComment
+		entry.TargetLabel = item.User.Username
+	default:
+		entry.Action = store.ModActionApproveComment
+		entry.TargetLabel = item.User.Username
+	}
+
+	return entry
+}
+
+// queueScope is the community whose queue is being moderated, nil for the
+// site-wide staff queue.
+func queueScope(r *http.Request) *int64 {
+	community, ok := r.Context().Value(communityCtx).(*store.CommunityDetails)
+	if !ok {
+		return nil
+	}
+
+	return &community.ID
+}
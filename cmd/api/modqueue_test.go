@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockModQueueStore struct {
+	store.ModQueueStore
+	item        *store.QueueItem
+	removed     bool
+	ban         *store.Ban
+	removeEntry *store.ModLogEntry
+	banEntry    *store.ModLogEntry
+}
+
+func (m *mockModQueueStore) GetItem(ctx context.Context, targetType string, targetID int64) (*store.QueueItem, error) {
+	if m.item == nil || m.item.TargetType != targetType || m.item.TargetID != targetID {
+		return nil, store.ErrNotFound
+	}
+	return m.item, nil
+}
+
+func (m *mockModQueueStore) Remove(ctx context.Context, targetType string, targetID int64, moderatorID *int64, entry *store.ModLogEntry) error {
+	m.removed = true
+	m.removeEntry = entry
+	return nil
+}
+
+func (m *mockModQueueStore) RemoveAndBan(ctx context.Context, targetType string, targetID int64, ban *store.Ban, exp *time.Duration, removeEntry, banEntry *store.ModLogEntry) error {
+	m.removed = true
+	m.ban = ban
+	m.removeEntry = removeEntry
+	m.banEntry = banEntry
+	return nil
+}
+
+// newQueueItem is a reported post in community 1 by the given author.
+func newQueueItem(authorID int64, username string) *store.QueueItem {
+	item := &store.QueueItem{TargetType: "post", TargetID: 10, PostTitle: "reported"}
+	item.Community.ID = 1
+	item.User.ID = authorID
+	item.User.Username = username
+	return item
+}
+
+// newQueueRequest is a request by user 3 to moderate post 10, through the
+// queue of the given community or, as staff, the site-wide queue when it is
+// nil.
+func newQueueRequest(community *store.CommunityDetails, body string) *http.Request {
+	user := &store.UserDetails{Role: testRoles["user"]}
+	if community == nil {
+		user.Role = testRoles["staff"]
+	}
+	user.ID = 3
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx := context.WithValue(req.Context(), userCtx, user)
+	if community != nil {
+		ctx = context.WithValue(ctx, communityCtx, community)
+	}
+
+	req = withURLParams(req.WithContext(ctx), "targetType", "post", "targetID", "10")
+
+	return withBody(req, body)
+}
+
+func newModeratedCommunity(id int64) *store.CommunityDetails {
+	community := &store.CommunityDetails{Role: testRoles["moderator"]}
+	community.ID = id
+	community.UserID = 1
+	return community
+}
+
+func TestRemoveQueueItem(t *testing.T) {
+	tests := []struct {
+		name      string
+		community *store.CommunityDetails
+		expected  int
+	}{
+		{"should remove content of the community", newModeratedCommunity(1), http.StatusNoContent},
+		{"should remove content from the site-wide queue", nil, http.StatusNoContent},
+		{"should hide content of another community", newModeratedCommunity(2), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			queue := &mockModQueueStore{item: newQueueItem(2, "author")}
+			app.store.ModQueue = queue
+
+			rr := httptest.NewRecorder()
+
+			app.removeQueueItemHandler(rr, newQueueRequest(tt.community, ""))
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected != http.StatusNoContent {
+				if queue.removed {
+					t.Error("expected the content to stay")
+				}
+				return
+			}
+
+			entry := queue.removeEntry
+			if entry == nil || entry.Action != store.ModActionRemovePost || *entry.TargetID != 10 || *entry.ActorID != 3 {
+				t.Errorf("expected a remove post entry by the moderator, got %+v", entry)
+			}
+		})
+	}
+}
+
+func TestRemoveAndBanQueueItem(t *testing.T) {
+	members := map[string]*store.Member{
+		"member": newTestMember(2, "member", "member"),
+		"peer":   newTestMember(4, "peer", "moderator"),
+	}
+
+	tests := []struct {
+		name      string
+		community *store.CommunityDetails
+		item      *store.QueueItem
+		expected  int
+	}{
+		{"should remove and ban a member", newModeratedCommunity(1), newQueueItem(2, "member"), http.StatusCreated},
+		{"should remove and ban an outsider", newModeratedCommunity(1), newQueueItem(5, "outsider"), http.StatusCreated},
+		{"should not ban the creator", newModeratedCommunity(1), newQueueItem(1, "creator"), http.StatusForbidden},
+		{"should not ban a moderator of the same rank", newModeratedCommunity(1), newQueueItem(4, "peer"), http.StatusForbidden},
+		{"should not ban themselves", newModeratedCommunity(1), newQueueItem(3, "moderator"), http.StatusForbidden},
+		{"should hide content of another community", newModeratedCommunity(2), newQueueItem(2, "member"), http.StatusNotFound},
+		{"should remove and ban from the site-wide queue", nil, newQueueItem(4, "peer"), http.StatusCreated},
+		{"should not ban themselves from the site-wide queue", nil, newQueueItem(3, "staff"), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			queue := &mockModQueueStore{item: tt.item}
+			app.store.ModQueue = queue
+			app.store.Members = &mockMemberStore{members: members}
+
+			rr := httptest.NewRecorder()
+
+			app.removeAndBanQueueItemHandler(rr, newQueueRequest(tt.community, `{"reason": "spam"}`))
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if tt.expected != http.StatusCreated {
+				if queue.removed {
+					t.Error("expected the content to stay")
+				}
+				return
+			}
+
+			if queue.ban == nil || queue.ban.User.ID != tt.item.User.ID || queue.ban.Type != store.BanTypeBan {
+				t.Errorf("expected the author to be banned, got %+v", queue.ban)
+			}
+
+			if queue.removeEntry == nil || queue.removeEntry.Action != store.ModActionRemovePost {
+				t.Errorf("expected a remove post entry, got %+v", queue.removeEntry)
+			}
+
+			if queue.banEntry == nil || queue.banEntry.Action != store.ModActionBanUser || queue.banEntry.Reason != "spam" {
+				t.Errorf("expected a ban entry with the reason, got %+v", queue.banEntry)
+			}
+		})
+	}
+}
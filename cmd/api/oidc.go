@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"github.com/skiba-mateusz/communiverse/internal/auth"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+const (
+	oidcStateTokenType = "oidc"
+	oidcStateCookie    = "oidc_state"
+)
+
+func (app *application) oidcStartHandler(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+
+	provider, ok := app.identityProviders[providerName]
+	if !ok {
+		app.notFoundResponse(w, r, fmt.Errorf("unknown identity provider: %s", providerName))
+		return
+	}
+
+	state, err := auth.GenerateRandomString(32)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	nonce, err := auth.GenerateRandomString(32)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	verifier, err := auth.GenerateRandomString(32)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+
+	stateToken, err := app.authenticator.GenerateToken(jwt.MapClaims{
+		"typ":      oidcStateTokenType,
+		"provider": providerName,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"exp":      now.Add(app.config.oidc.stateExp).Unix(),
+		"iat":      now.Unix(),
+		"nbf":      now.Unix(),
+		"iss":      app.config.auth.token.iss,
+		"aud":      app.config.auth.token.iss,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     "/v1/auth/oidc",
+		MaxAge:   int(app.config.oidc.stateExp.Seconds()),
+		HttpOnly: true,
+		Secure:   app.config.env == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, nonce, auth.CodeChallenge(verifier)), http.StatusFound)
+}
+
+func (app *application) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+
+	provider, ok := app.identityProviders[providerName]
+	if !ok {
+		app.notFoundResponse(w, r, fmt.Errorf("unknown identity provider: %s", providerName))
+		return
+	}
+
+	qs := r.URL.Query()
+
+	if errCode := qs.Get("error"); errCode != "" {
+		app.specificUnauthorizedResponse(w, r, fmt.Errorf("identity provider returned error: %s", errCode))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("missing login state, start the login again"))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/v1/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   app.config.env == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	stateToken, err := app.authenticator.ValidateToken(cookie.Value)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	claims, _ := stateToken.Claims.(jwt.MapClaims)
+	expectedState, _ := claims["state"].(string)
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+
+	if claims["typ"] != oidcStateTokenType || claims["provider"] != providerName ||
+		subtle.ConstantTimeCompare([]byte(expectedState), []byte(qs.Get("state"))) != 1 {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid login state"))
+		return
+	}
+
+	ctx := r.Context()
+
+	identity, err := provider.Exchange(ctx, qs.Get("code"), verifier, nonce)
+	if err != nil {
+		app.specificUnauthorizedResponse(w, r, fmt.Errorf("could not verify identity: %w", err))
+		return
+	}
+
+	user, err := app.getOrCreateIdentityUser(r, identity)
+	if err != nil {
+		switch err {
+		case errUnverifiedEmail:
+			app.specificUnauthorizedResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	fragment := url.Values{}
+
+	if user.MFAEnabled {
+		mfaToken, err := app.generateMFAToken(user.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		fragment.Set("mfaToken", mfaToken)
+	} else {
+		tokens, err := app.createSession(r, user.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		fragment.Set("accessToken", tokens.AccessToken)
+		fragment.Set("refreshToken", tokens.RefreshToken)
+	}
+
+	redirectURL := fmt.Sprintf("%s/auth/oidc#%s", app.config.frontendURL, fragment.Encode())
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+var errUnverifiedEmail = fmt.Errorf("identity provider did not return a verified email address")
+
+func (app *application) getOrCreateIdentityUser(r *http.Request, identity *auth.Identity) (*store.UserDetails, error) {
+	ctx := r.Context()
+
+	user, err := app.store.Users.GetByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if err != store.ErrNotFound {
+		return nil, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errUnverifiedEmail
+	}
+
+	user, err = app.store.Users.GetByEmail(ctx, identity.Email)
+	if err == nil {
+		// The provider verified the email but whoever registered the inactive
+		// account never did, so it's claimed with a fresh random password
+		// rather than keeping the one they chose.
+		var password []byte
+		if !user.IsActive {
+			if err = user.Password.Set(uuid.New().String()); err != nil {
+				return nil, err
+			}
+			password = user.Password.Hash
+		}
+
+		if err = app.store.Users.LinkIdentity(ctx, user.ID, identity.Provider, identity.Subject, password); err != nil {
+			return nil, err
+		}
+
+		user.IsActive = true
+		return user, nil
+	}
+	if err != store.ErrNotFound {
+		return nil, err
+	}
+
+	name := identity.Name
+	if len(name) < 3 {
+		name = strings.Split(identity.Email, "@")[0]
+	}
+
+	baseUsername := identity.Username
+	if baseUsername == "" {
+		baseUsername = strings.Split(identity.Email, "@")[0]
+	}
+	baseUsername = slug.Make(baseUsername)
+	if len(baseUsername) > 80 {
+		baseUsername = baseUsername[:80]
+	}
+
+	user = &store.UserDetails{
+		BaseUser: store.BaseUser{
+			Name: name,
+		},
+		Email: identity.Email,
+		Role: store.Role{
+			Name: "user",
+		},
+	}
+
+	if err = user.Password.Set(uuid.New().String()); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 3; i++ {
+		user.Username = baseUsername + "-" + strconv.FormatUint(uint64(uuid.New().ID()), 36)
+
+		err = app.store.Users.CreateWithIdentity(ctx, user, identity.Provider, identity.Subject)
+		if err != store.ErrDuplicateUsername {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/auth"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type linkingUserStore struct {
+	store.MockUserStore
+	user     *store.UserDetails
+	linked   bool
+	password []byte
+}
+
+func (m *linkingUserStore) GetByEmail(ctx context.Context, email string) (*store.UserDetails, error) {
+	return m.user, nil
+}
+
+func (m *linkingUserStore) LinkIdentity(ctx context.Context, userID int64, provider, subject string, password []byte) error {
+	m.linked = true
+	m.password = password
+	return nil
+}
+
+func TestGetOrCreateIdentityUser(t *testing.T) {
+	identity := &auth.Identity{
+		Provider:      "test",
+		Subject:       "subject",
+		Email:         "test@example.com",
+		EmailVerified: true,
+	}
+
+	newUser := func(t *testing.T, active bool) *store.UserDetails {
+		user := &store.UserDetails{Email: identity.Email, IsActive: active}
+		if err := user.Password.Set("registered password"); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	t.Run("should replace the password of an unactivated account", func(t *testing.T) {
+		app := newTestApplication(t)
+		users := &linkingUserStore{user: newUser(t, false)}
+		app.store.Users = users
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+		user, err := app.getOrCreateIdentityUser(req, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !users.linked || users.password == nil {
+			t.Fatal("expected the account to be linked with a new password")
+		}
+
+		if user.Password.Matches("registered password") {
+			t.Error("expected the registered password to be replaced")
+		}
+
+		if !user.IsActive {
+			t.Error("expected the account to be activated")
+		}
+	})
+
+	t.Run("should keep the password of an active account", func(t *testing.T) {
+		app := newTestApplication(t)
+		users := &linkingUserStore{user: newUser(t, true)}
+		app.store.Users = users
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+		user, err := app.getOrCreateIdentityUser(req, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !users.linked || users.password != nil {
+			t.Fatal("expected the account to be linked without a new password")
+		}
+
+		if !user.Password.Matches("registered password") {
+			t.Error("expected the password to be kept")
+		}
+	})
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/automod"
 	"github.com/skiba-mateusz/communiverse/internal/store"
 )
 
@@ -12,12 +15,26 @@ type postKey string
 
 const (
 	postCtx postKey = "post"
+
+	maxPinnedPosts = 3
+)
+
+var (
+	errPostLocked          = fmt.Errorf("this post is locked and no longer accepts comments or votes")
+	errPublishAtRequired   = fmt.Errorf("scheduled posts need a publish time")
+	errPublishAtUnexpected = fmt.Errorf("only scheduled posts can have a publish time")
+	errPublishAtInPast     = fmt.Errorf("publish time must be in the future")
+	errAlreadyPublished    = fmt.Errorf("published posts can't be turned back into drafts")
+	errPostDeleted         = fmt.Errorf("this post has been deleted")
 )
 
 type CreatePostPayload struct {
-	Title   string   `json:"title" validate:"required,min=8,max=100"`
-	Content string   `json:"content" validate:"required,min=100,max=2500"`
-	Tags    []string `json:"tags" validate:"required"`
+	Title     string     `json:"title" validate:"required,min=8,max=100"`
+	Content   string     `json:"content" validate:"required,min=100,max=2500"`
+	Tags      []string   `json:"tags" validate:"required"`
+	FlairID   *int64     `json:"flairID" validate:"omitempty,min=1"`
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publishAt"`
 }
 
 func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +51,11 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 
 	community := getCommunityFromContext(r)
 
+	flair, ok := app.getUsableFlair(w, r, payload.FlairID, store.FlairTypePost)
+	if !ok {
+		return
+	}
+
 	ctx := r.Context()
 
 	slug, err := app.store.Common.GenerateUniqueSlug(ctx, payload.Title, "posts")
@@ -52,22 +74,91 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 			CommunityID: community.ID,
 			UserID:      user.ID,
 			Content:     payload.Content,
+			Flair:       flair,
 		},
 	}
 
+	status := payload.Status
+	if status == "" {
+		status = store.PostStatusPublished
+	}
+
+	if err = schedulePost(post, status, payload.PublishAt); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
 	if err = app.store.Posts.Create(ctx, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
+	// Scheduled posts are checked by the publish job once they go live.
+	if post.Status == store.PostStatusPublished {
+		app.runPostAutoMod(ctx, getCommunityFromContext(r), getUserFromContext(r), post)
+	}
+
 	if err = jsonResponse(w, http.StatusCreated, post); err != nil {
 		app.internalServerError(w, r, err)
 	}
 }
 
+// schedulePost sets the post status, only scheduled posts carry a publish time
+// and it must be in the future.
+func schedulePost(post *store.PostDetails, status string, publishAt *time.Time) error {
+	switch {
+	case status != store.PostStatusScheduled && publishAt != nil:
+		return errPublishAtUnexpected
+	case status == store.PostStatusScheduled && publishAt == nil:
+		return errPublishAtRequired
+	case status == store.PostStatusScheduled && !publishAt.After(time.Now()):
+		return errPublishAtInPast
+	}
+
+	post.Status = status
+	post.PublishAt = nil
+
+	if publishAt != nil {
+		at := publishAt.UTC().Format(time.RFC3339)
+		post.PublishAt = &at
+	}
+
+	return nil
+}
+
+// runPostAutoMod runs AutoModerator once a post is published, drafts and
+// scheduled posts are only visible to their author.
+func (app *application) runPostAutoMod(ctx context.Context, community *store.CommunityDetails, author *store.UserDetails, post *store.PostDetails) bool {
+	return app.runAutoMod(ctx, community, author, autoModTarget{
+		Type:   automod.TargetPost,
+		ID:     post.ID,
+		PostID: post.ID,
+		Label:  post.Title,
+		Subject: automod.Subject{
+			Type:    automod.TargetPost,
+			Title:   post.Title,
+			Content: post.Content,
+			Tags:    post.Tags,
+		},
+	})
+}
+
 func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	post := getPostFromContext(r)
 
+	post.Media = []store.PostMedia{}
+
+	if post.Tombstone == "" {
+		media, err := app.store.Media.GetPostMedia(r.Context(), post.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		app.setMediaURLs(media)
+		post.Media = media
+	}
+
 	if err := jsonResponse(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err)
 	}
@@ -76,9 +167,33 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
 	post := getPostFromContext(r)
 
-	ctx := r.Context()
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
 
-	if err := app.store.Posts.Delete(ctx, post.ID); err != nil {
+	ctx := r.Context()
+	user := getUserFromContext(r)
+	isAuthor := user.ID == post.UserID
+
+	// Authors delete their own posts, anyone else deleting it is a moderator
+	// removing it.
+	if isAuthor {
+		err = app.store.Posts.Delete(ctx, post.ID)
+	} else {
+		entry := modLogEntry(r, store.ModLogEntry{
+			CommunityID: &post.CommunityID,
+			Action:      store.ModActionRemovePost,
+			TargetType:  "post",
+			TargetID:    &post.ID,
+			TargetLabel: post.Title,
+			Reason:      reason,
+		})
+
+		err = app.store.ModQueue.Remove(ctx, "post", post.ID, &user.ID, entry)
+	}
+	if err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -92,9 +207,12 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 }
 
 type UpdatePostPayload struct {
-	Title   *string   `json:"title" validate:"omitempty,min=8,max=100"`
-	Content *string   `json:"content" validate:"omitempty,min=32,max=1000"`
-	Tags    *[]string `json:"tags" validate:"omitempty"`
+	Title     *string    `json:"title" validate:"omitempty,min=8,max=100"`
+	Content   *string    `json:"content" validate:"omitempty,min=32,max=1000"`
+	Tags      *[]string  `json:"tags" validate:"omitempty"`
+	FlairID   *int64     `json:"flairID" validate:"omitempty,min=0"`
+	Status    *string    `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publishAt"`
 }
 
 func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request) {
@@ -112,6 +230,11 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	post := getPostFromContext(r)
 	ctx := r.Context()
 
+	if post.Tombstone != "" {
+		app.specificForbiddenResponse(w, r, errPostDeleted)
+		return
+	}
+
 	if payload.Title != nil {
 		post.Title = *payload.Title
 
@@ -129,8 +252,55 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	if payload.Tags != nil {
 		post.Tags = *payload.Tags
 	}
+	if payload.FlairID != nil {
+		flair, ok := app.getUsableFlair(w, r, payload.FlairID, store.FlairTypePost)
+		if !ok {
+			return
+		}
+
+		post.Flair = flair
+	}
+
+	wasPublished := post.Status == store.PostStatusPublished
 
-	if err := app.store.Posts.Update(ctx, post); err != nil {
+	if payload.Status != nil || payload.PublishAt != nil {
+		status := post.Status
+		if payload.Status != nil {
+			status = *payload.Status
+		}
+
+		if post.Status == store.PostStatusPublished && status != store.PostStatusPublished {
+			app.badRequestResponse(w, r, errAlreadyPublished)
+			return
+		}
+
+		// Publishing, now or later, is contributing to the community, which
+		// banned and muted authors can't do.
+		if status != store.PostStatusDraft && status != post.Status && !app.checkNotRestricted(w, r, post.UserID) {
+			return
+		}
+
+		if err := schedulePost(post, status, payload.PublishAt); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	user := getUserFromContext(r)
+
+	// Admins editing someone else's post are moderating it.
+	var entry *store.ModLogEntry
+	if user.ID != post.UserID {
+		entry = modLogEntry(r, store.ModLogEntry{
+			CommunityID: &post.CommunityID,
+			Action:      store.ModActionEditPost,
+			TargetType:  "post",
+			TargetID:    &post.ID,
+			TargetLabel: post.Title,
+		})
+	}
+
+	if err := app.store.Posts.Update(ctx, post, user.ID, entry); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
@@ -140,6 +310,10 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !wasPublished && post.Status == store.PostStatusPublished {
+		app.runPostAutoMod(ctx, getCommunityFromContext(r), user, post)
+	}
+
 	if err := jsonResponse(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err)
 	}
@@ -264,6 +438,98 @@ func (app *application) votePostHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (app *application) pinPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderatePost(w, r, store.ModActionPinPost)
+}
+
+func (app *application) unpinPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderatePost(w, r, store.ModActionUnpinPost)
+}
+
+func (app *application) lockPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderatePost(w, r, store.ModActionLockPost)
+}
+
+func (app *application) unlockPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderatePost(w, r, store.ModActionUnlockPost)
+}
+
+// moderatePost pins, unpins, locks or unlocks the post in context and records
+// the action in the mod log.
+func (app *application) moderatePost(w http.ResponseWriter, r *http.Request, action string) {
+	reason, err := modReason(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post := getPostFromContext(r)
+	ctx := r.Context()
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &post.CommunityID,
+		Action:      action,
+		TargetType:  "post",
+		TargetID:    &post.ID,
+		TargetLabel: post.Title,
+		Reason:      reason,
+	})
+
+	switch action {
+	case store.ModActionPinPost:
+		err = app.store.Posts.SetPinned(ctx, post.ID, true, maxPinnedPosts, entry)
+	case store.ModActionUnpinPost:
+		err = app.store.Posts.SetPinned(ctx, post.ID, false, maxPinnedPosts, entry)
+	case store.ModActionLockPost:
+		err = app.store.Posts.SetLocked(ctx, post.ID, true, entry)
+	case store.ModActionUnlockPost:
+		err = app.store.Posts.SetLocked(ctx, post.ID, false, entry)
+	}
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrPinLimit:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireUnlocked rejects new comments and votes on a locked post, moderators
+// can still reply so they can explain the lock.
+func (app *application) requireUnlocked(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		post := getPostFromContext(r)
+		if post.Tombstone != "" {
+			app.specificForbiddenResponse(w, r, errPostDeleted)
+			return
+		}
+
+		if !post.Locked {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		isModerator, err := app.hasCommunityRole(r, "moderator")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !isModerator {
+			app.specificForbiddenResponse(w, r, errPostLocked)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) postContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		slug := chi.URLParam(r, "postSlug")
@@ -281,6 +547,13 @@ func (app *application) postContextMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Slugs are global, so a post looked up through another community's
+		// URL would be judged by that community's roles and visibility.
+		if post.CommunityID != getCommunityFromContext(r).ID {
+			app.notFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+
 		post.Community.ThumbnailURL = app.generateAssetURL(post.Community.ThumbnailID, "thumbnails")
 		post.User.AvatarURL = app.generateAssetURL(post.User.AvatarID, "avatars")
 
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockPostStore struct {
+	store.PostStore
+	post      *store.PostDetails
+	pinnedErr error
+	pinned    *bool
+	locked    *bool
+	entry     *store.ModLogEntry
+}
+
+func (m *mockPostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*store.PostDetails, error) {
+	if m.post == nil || m.post.Slug != slug {
+		return nil, store.ErrNotFound
+	}
+	return m.post, nil
+}
+
+func (m *mockPostStore) SetPinned(ctx context.Context, id int64, pinned bool, maxPinned int, entry *store.ModLogEntry) error {
+	if m.pinnedErr != nil {
+		return m.pinnedErr
+	}
+	m.pinned = &pinned
+	m.entry = entry
+	return nil
+}
+
+func (m *mockPostStore) SetLocked(ctx context.Context, id int64, locked bool, entry *store.ModLogEntry) error {
+	m.locked = &locked
+	m.entry = entry
+	return nil
+}
+
+func TestPostContextMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	post := &store.PostDetails{}
+	post.Slug = "post"
+	post.CommunityID = 1
+
+	tests := []struct {
+		name        string
+		communityID int64
+		expected    int
+	}{
+		{"should load a post of the community", 1, http.StatusOK},
+		{"should hide a post of another community", 2, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.store.Posts = &mockPostStore{post: post}
+
+			community := &store.CommunityDetails{}
+			community.ID = tt.communityID
+
+			req := newCommunityRequest(http.MethodGet, community, &store.UserDetails{}, nil)
+			req = withURLParams(req, "postSlug", "post")
+			rr := httptest.NewRecorder()
+
+			app.postContextMiddleware(ok).ServeHTTP(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func TestModeratePost(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    string
+		pinnedErr error
+		expected  int
+		pinned    *bool
+		locked    *bool
+	}{
+		{"should pin a post", store.ModActionPinPost, nil, http.StatusNoContent, ptr(true), nil},
+		{"should unpin a post", store.ModActionUnpinPost, nil, http.StatusNoContent, ptr(false), nil},
+		{"should not pin past the limit", store.ModActionPinPost, store.ErrPinLimit, http.StatusConflict, nil, nil},
+		{"should lock a post", store.ModActionLockPost, nil, http.StatusNoContent, nil, ptr(true)},
+		{"should unlock a post", store.ModActionUnlockPost, nil, http.StatusNoContent, nil, ptr(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			posts := &mockPostStore{pinnedErr: tt.pinnedErr}
+			app.store.Posts = posts
+
+			post := &store.PostDetails{}
+			post.ID = 10
+			post.CommunityID = 1
+			user := &store.UserDetails{}
+			user.ID = 3
+
+			req := newCommunityRequest(http.MethodPut, &store.CommunityDetails{}, user, post)
+			rr := httptest.NewRecorder()
+
+			app.moderatePost(rr, req, tt.action)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if !reflect.DeepEqual(posts.pinned, tt.pinned) || !reflect.DeepEqual(posts.locked, tt.locked) {
+				t.Fatalf("expected pinned %v and locked %v, got %v and %v", tt.pinned, tt.locked, posts.pinned, posts.locked)
+			}
+
+			if tt.expected != http.StatusNoContent {
+				return
+			}
+
+			entry := posts.entry
+			if entry == nil || entry.Action != tt.action || *entry.TargetID != 10 || *entry.ActorID != 3 {
+				t.Errorf("expected a %s entry by the moderator, got %+v", tt.action, entry)
+			}
+		})
+	}
+}
+
+func TestRequireUnlocked(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name          string
+		locked        bool
+		tombstone     string
+		communityRole store.Role
+		expected      int
+	}{
+		{"should let members reply to an unlocked post", false, "", testRoles["member"], http.StatusOK},
+		{"should stop members replying to a locked post", true, "", testRoles["member"], http.StatusForbidden},
+		{"should let moderators reply to a locked post", true, "", testRoles["moderator"], http.StatusOK},
+		{"should stop replies to a deleted post", false, store.TombstoneDeleted, testRoles["moderator"], http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplicationWithRoles(t)
+
+			community := &store.CommunityDetails{Role: tt.communityRole}
+			community.UserID = 1
+			post := &store.PostDetails{}
+			post.Locked = tt.locked
+			post.Tombstone = tt.tombstone
+			user := &store.UserDetails{Role: testRoles["user"]}
+			user.ID = 3
+
+			req := newCommunityRequest(http.MethodPost, community, user, post)
+			rr := httptest.NewRecorder()
+
+			app.requireUnlocked(ok)(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var errUnknownRule = fmt.Errorf("rule does not belong to this community")
+
+type ReportPayload struct {
+	RuleID  *int64 `json:"ruleID" validate:"required_without=Reason"`
+	Reason  string `json:"reason" validate:"required_without=RuleID,omitempty,oneof=spam harassment hate violence misinformation nsfw other"`
+	Details string `json:"details" validate:"max=500"`
+}
+
+func (app *application) reportPostHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromContext(r)
+	app.createReport(w, r, "post", post.ID)
+}
+
+func (app *application) reportCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := getCommentFromContext(r)
+	app.createReport(w, r, "comment", comment.ID)
+}
+
+func (app *application) createReport(w http.ResponseWriter, r *http.Request, targetType string, targetID int64) {
+	var payload ReportPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	if payload.RuleID != nil {
+		rules, err := app.store.Rules.GetCommunityRules(ctx, community.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !slices.ContainsFunc(rules, func(rule store.Rule) bool { return rule.ID == *payload.RuleID }) {
+			app.badRequestResponse(w, r, errUnknownRule)
+			return
+		}
+	}
+
+	report := &store.Report{
+		ReporterID:  &user.ID,
+		CommunityID: community.ID,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		RuleID:      payload.RuleID,
+		Reason:      payload.Reason,
+		Details:     payload.Details,
+	}
+
+	if err := app.store.Reports.Create(ctx, report); err != nil {
+		switch err {
+		case store.ErrDuplicateReport:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusCreated, report); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
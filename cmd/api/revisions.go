@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/skiba-mateusz/communiverse/internal/diff"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+// RevisionResponse is a revision along with what changed since the previous
+// one, the original version has no diff.
+type RevisionResponse struct {
+	store.Revision
+	TitleDiff   []diff.Chunk `json:"titleDiff,omitempty"`
+	ContentDiff []diff.Chunk `json:"contentDiff"`
+}
+
+type PaginatedRevisionsResponse struct {
+	Items []RevisionResponse `json:"items"`
+	Meta  store.Meta         `json:"meta"`
+}
+
+func (app *application) getPostRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromContext(r)
+
+	query, ok := app.readRevisionsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, meta, err := app.store.Revisions.GetPostRevisions(r.Context(), post.ID, query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, app.diffRevisions(revisions, meta)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) getCommentRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	comment := getCommentFromContext(r)
+
+	query, ok := app.readRevisionsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, meta, err := app.store.Revisions.GetCommentRevisions(r.Context(), comment.ID, query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, app.diffRevisions(revisions, meta)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) readRevisionsQuery(w http.ResponseWriter, r *http.Request) (store.PaginatedRevisionsQuery, bool) {
+	query := store.PaginatedRevisionsQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	query, err := query.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return query, false
+	}
+
+	if err = Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return query, false
+	}
+
+	return query, true
+}
+
+func (app *application) diffRevisions(revisions []store.Revision, meta store.Meta) PaginatedRevisionsResponse {
+	items := make([]RevisionResponse, len(revisions))
+
+	for i, revision := range revisions {
+		revision.Editor.AvatarURL = app.generateAssetURL(revision.Editor.AvatarID, "avatars")
+		items[i].Revision = revision
+
+		if revision.PreviousContent == nil {
+			continue
+		}
+
+		previousTitle := ""
+		if revision.PreviousTitle != nil {
+			previousTitle = *revision.PreviousTitle
+		}
+
+		if previousTitle != revision.Title {
+			items[i].TitleDiff = diff.Words(previousTitle, revision.Title)
+		}
+		items[i].ContentDiff = diff.Words(*revision.PreviousContent, revision.Content)
+	}
+
+	return PaginatedRevisionsResponse{
+		Items: items,
+		Meta:  meta,
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type RulePayload struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title" validate:"required,min=3,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type UpdateRulesPayload struct {
+	Rules []RulePayload `json:"rules" validate:"max=15,dive"`
+}
+
+func (app *application) getRulesHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	rules, err := app.store.Rules.GetCommunityRules(r.Context(), community.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, rules); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) updateRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateRulesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+
+	rules := make([]store.Rule, len(payload.Rules))
+	for i, rule := range payload.Rules {
+		rules[i] = store.Rule{
+			ID:          rule.ID,
+			Title:       rule.Title,
+			Description: rule.Description,
+		}
+	}
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionUpdateRules,
+		TargetType:  "community",
+		TargetID:    &community.ID,
+		TargetLabel: community.Name,
+	})
+
+	if err := app.store.Rules.Replace(r.Context(), community.ID, rules, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, rules); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type sessionKey string
+
+const (
+	sessionCtx sessionKey = "session"
+)
+
+func (app *application) getCurrentUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	current := getSessionFromContext(r)
+
+	sessions, err := app.store.Sessions.GetUserSessions(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.ID
+	}
+
+	if err = jsonResponse(w, http.StatusOK, sessions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) revokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	current := getSessionFromContext(r)
+
+	if err := app.store.Sessions.RevokeOthers(r.Context(), user.ID, current.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) revokeCurrentUserSessionHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	if err = app.store.Sessions.Revoke(r.Context(), id, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) revokeUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	ctx := r.Context()
+
+	user, err := app.store.Users.GetByUsername(ctx, username)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = app.store.Sessions.RevokeAll(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("user sessions revoked", "user", user.ID, "by", getUserFromContext(r).ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func getSessionFromContext(r *http.Request) *store.Session {
+	session := r.Context().Value(sessionCtx).(*store.Session)
+	return session
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/skiba-mateusz/communiverse/internal/auth"
 	"github.com/skiba-mateusz/communiverse/internal/store"
+	"github.com/skiba-mateusz/communiverse/internal/throttle"
 	"go.uber.org/zap"
 	"net/http"
 	"net/http/httptest"
@@ -15,11 +16,17 @@ func newTestApplication(t *testing.T) *application {
 	logger := zap.NewNop().Sugar()
 	mockStore := store.NewMockStore()
 	testAuthenticator := &auth.TestAuthenticator{}
+	testLimiters := limiters{
+		account: throttle.NewMemoryLimiter(throttle.Policy{}),
+		ip:      throttle.NewMemoryLimiter(throttle.Policy{}),
+		email:   throttle.NewMemoryLimiter(throttle.Policy{}),
+	}
 
 	return &application{
 		logger:        logger,
 		store:         mockStore,
 		authenticator: testAuthenticator,
+		limiters:      testLimiters,
 	}
 }
 
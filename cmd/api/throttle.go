@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/mailer"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+	"github.com/skiba-mateusz/communiverse/internal/throttle"
+)
+
+type limiters struct {
+	account throttle.Limiter
+	ip      throttle.Limiter
+	email   throttle.Limiter
+}
+
+// pruner is implemented by limiters that keep attempts outside the process,
+// the in memory limiter sweeps its own entries.
+type pruner interface {
+	Prune(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+func newLimiters(cfg throttleConfig, db *sql.DB) (limiters, error) {
+	switch cfg.backend {
+	case "memory":
+		return limiters{
+			account: throttle.NewMemoryLimiter(cfg.account),
+			ip:      throttle.NewMemoryLimiter(cfg.ip),
+			email:   throttle.NewMemoryLimiter(cfg.email),
+		}, nil
+	case "postgres":
+		return limiters{
+			account: throttle.NewPostgresLimiter(db, cfg.account),
+			ip:      throttle.NewPostgresLimiter(db, cfg.ip),
+			email:   throttle.NewPostgresLimiter(db, cfg.email),
+		}, nil
+	default:
+		return limiters{}, fmt.Errorf("unknown throttle backend: %q", cfg.backend)
+	}
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func mfaThrottleKey(userID int64) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+func passwordThrottleKey(userID int64) string {
+	return fmt.Sprintf("password:%d", userID)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (app *application) loginRetryAfter(ctx context.Context, accountKey, ip string) (time.Duration, error) {
+	accountRetry, err := app.limiters.account.Check(ctx, accountKey)
+	if err != nil {
+		return 0, err
+	}
+
+	ipRetry, err := app.limiters.ip.Check(ctx, ipThrottleKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	return max(accountRetry, ipRetry), nil
+}
+
+func (app *application) loginFailed(ctx context.Context, accountKey, ip string, user *store.UserDetails) {
+	if _, err := app.limiters.ip.Fail(ctx, ipThrottleKey(ip)); err != nil {
+		app.logger.Errorw("error recording failed login", "ip", ip, "error", err)
+	}
+
+	attempt, err := app.limiters.account.Fail(ctx, accountKey)
+	if err != nil {
+		app.logger.Errorw("error recording failed login", "key", accountKey, "error", err)
+		return
+	}
+
+	if !attempt.JustLocked || user == nil {
+		return
+	}
+
+	app.logger.Warnw("account locked", "user", user.ID, "until", attempt.BlockedUntil)
+
+	vars := struct {
+		Username    string
+		LockedUntil string
+		ResetURL    string
+	}{
+		Username:    user.Username,
+		LockedUntil: attempt.BlockedUntil.UTC().Format("January 2, 2006 15:04 MST"),
+		ResetURL:    fmt.Sprintf("%s/auth/forgot-password", app.config.frontendURL),
+	}
+
+	isProd := app.config.env == "production"
+
+	statusCode, err := app.mailer.Send(mailer.AccountLockedTemplate, user.Username, user.Email, vars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending account locked email", "error", err)
+		return
+	}
+
+	app.logger.Infow("account locked email sent", "status code", statusCode)
+}
+
+func (app *application) loginSucceeded(ctx context.Context, accountKey string) {
+	if err := app.limiters.account.Reset(ctx, accountKey); err != nil {
+		app.logger.Errorw("error resetting login attempts", "key", accountKey, "error", err)
+	}
+}
+
+// throttleEmail counts a request that sends an email to the given address,
+// both per address and per client IP, and reports how long the caller has to
+// wait when either has been used up.
+func (app *application) throttleEmail(ctx context.Context, purpose, email, ip string) (time.Duration, error) {
+	keys := []string{
+		fmt.Sprintf("%s:%s", purpose, strings.ToLower(email)),
+		fmt.Sprintf("%s:%s", purpose, ipThrottleKey(ip)),
+	}
+
+	for _, key := range keys {
+		retry, err := app.limiters.email.Check(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		if retry > 0 {
+			return retry, nil
+		}
+	}
+
+	for _, key := range keys {
+		if _, err := app.limiters.email.Fail(ctx, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/auth"
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+const (
+	personalAccessTokenPrefix = "cvpat_"
+
+	scopeRead          = "read"
+	scopePostsWrite    = "posts:write"
+	scopeCommentsWrite = "comments:write"
+)
+
+// tokenScopeRoutes lists every request a personal access token may perform,
+// anything not listed requires a regular login session. TestTokenScopeRoutes
+// fails until a new route is given its scope here, or left to sessions only.
+var tokenScopeRoutes = []struct {
+	methods []string
+	pattern *regexp.Regexp
+	scope   string
+}{
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/[^/]+/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/[^/]+/(rules|members|flairs)/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/(comments|media)/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/posts/?$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/users/me/(communities|drafts|feed)$`), scopeRead},
+	{[]string{http.MethodGet}, regexp.MustCompile(`^/v1/users/[^/]+/?$`), scopeRead},
+	{[]string{http.MethodPost}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/?$`), scopePostsWrite},
+	{[]string{http.MethodPatch, http.MethodDelete}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/?$`), scopePostsWrite},
+	{[]string{http.MethodPut}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/vote$`), scopePostsWrite},
+	{[]string{http.MethodPost}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/media/?$`), scopePostsWrite},
+	{[]string{http.MethodPut}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/media/order$`), scopePostsWrite},
+	{[]string{http.MethodPatch, http.MethodDelete}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/media/[0-9]+$`), scopePostsWrite},
+	{[]string{http.MethodPost}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/comments/?$`), scopeCommentsWrite},
+	{[]string{http.MethodPatch, http.MethodDelete}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/comments/[^/]+/?$`), scopeCommentsWrite},
+	{[]string{http.MethodPut}, regexp.MustCompile(`^/v1/communities/[^/]+/posts/[^/]+/comments/[^/]+/vote$`), scopeCommentsWrite},
+}
+
+func requiredTokenScope(r *http.Request) (string, bool) {
+	for _, route := range tokenScopeRoutes {
+		if slices.Contains(route.methods, r.Method) && route.pattern.MatchString(r.URL.Path) {
+			return route.scope, true
+		}
+	}
+
+	return "", false
+}
+
+type CreateTokenPayload struct {
+	Name          string   `json:"name" validate:"required,min=3,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read posts:write comments:write"`
+	ExpiresInDays *int     `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
+
+type CreateTokenResponse struct {
+	store.AccessToken
+	Token string `json:"token"`
+}
+
+func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	plainToken, hashToken, err := generatePersonalAccessToken()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	var exp *time.Duration
+	if payload.ExpiresInDays != nil {
+		d := time.Hour * 24 * time.Duration(*payload.ExpiresInDays)
+		exp = &d
+	}
+
+	scopes := slices.Clone(payload.Scopes)
+	slices.Sort(scopes)
+
+	token := &store.AccessToken{
+		UserID: user.ID,
+		Name:   payload.Name,
+		Scopes: slices.Compact(scopes),
+	}
+
+	if err = app.store.Tokens.Create(r.Context(), token, hashToken, exp); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := CreateTokenResponse{
+		AccessToken: *token,
+		Token:       plainToken,
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) getTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	tokens, err := app.store.Tokens.GetUserTokens(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, tokens); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deleteTokenHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	if err = app.store.Tokens.Delete(r.Context(), id, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func generatePersonalAccessToken() (string, string, error) {
+	random, err := auth.GenerateRandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	plainToken := fmt.Sprintf("%s%s", personalAccessTokenPrefix, random)
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	return plainToken, hashToken, nil
+}
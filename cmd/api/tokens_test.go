@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequiredTokenScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  string
+		ok     bool
+	}{
+		{http.MethodGet, "/v1/users/me", scopeRead, true},
+		{http.MethodGet, "/v1/communities/go/modlog", "", false},
+		{http.MethodGet, "/v1/users/me/tokens", "", false},
+		{http.MethodPost, "/v1/communities/go/posts", scopePostsWrite, true},
+		{http.MethodPut, "/v1/communities/go/posts/hello/vote", scopePostsWrite, true},
+		{http.MethodPost, "/v1/communities/go/posts/hello/comments", scopeCommentsWrite, true},
+		{http.MethodDelete, "/v1/communities/go/posts/hello/comments/1", scopeCommentsWrite, true},
+		{http.MethodDelete, "/v1/communities/go", "", false},
+		{http.MethodPost, "/v1/users/me/tokens", "", false},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scope, ok := requiredTokenScope(req)
+		if scope != tt.scope || ok != tt.ok {
+			t.Errorf("%s %s: expected (%q, %v), got (%q, %v)", tt.method, tt.path, tt.scope, tt.ok, scope, ok)
+		}
+	}
+}
+
+// TestTokenScopeRoutes lists every route with the scope a personal access token
+// needs for it, "" meaning login sessions only, so new routes can't be added
+// without deciding whether tokens may use them.
+func TestTokenScopeRoutes(t *testing.T) {
+	scopes := map[string]string{
+		"GET /.well-known/jwks.json":                                                                        "",
+		"POST /v1/auth/forgot-password":                                                                     "",
+		"POST /v1/auth/login":                                                                               "",
+		"POST /v1/auth/logout":                                                                              "",
+		"POST /v1/auth/magic-link":                                                                          "",
+		"PUT /v1/auth/magic-link/{token}":                                                                   "",
+		"POST /v1/auth/mfa":                                                                                 "",
+		"GET /v1/auth/oidc/{provider}/callback":                                                             "",
+		"GET /v1/auth/oidc/{provider}/start":                                                                "",
+		"POST /v1/auth/refresh":                                                                             "",
+		"POST /v1/auth/register":                                                                            "",
+		"POST /v1/auth/resend-activation":                                                                   "",
+		"PUT /v1/auth/reset-password/{token}":                                                               "",
+		"POST /v1/communities/":                                                                             "",
+		"GET /v1/communities/":                                                                              scopeRead,
+		"PATCH /v1/communities/{communitySlug}/":                                                            "",
+		"GET /v1/communities/{communitySlug}/":                                                              scopeRead,
+		"DELETE /v1/communities/{communitySlug}/":                                                           "",
+		"PUT /v1/communities/{communitySlug}/automod/":                                                      "",
+		"GET /v1/communities/{communitySlug}/automod/":                                                      "",
+		"POST /v1/communities/{communitySlug}/automod/dry-run":                                              "",
+		"GET /v1/communities/{communitySlug}/bans/":                                                         "",
+		"POST /v1/communities/{communitySlug}/bans/":                                                        "",
+		"DELETE /v1/communities/{communitySlug}/bans/{id}":                                                  "",
+		"PUT /v1/communities/{communitySlug}/flair":                                                         "",
+		"GET /v1/communities/{communitySlug}/flairs/":                                                       scopeRead,
+		"POST /v1/communities/{communitySlug}/flairs/":                                                      "",
+		"PATCH /v1/communities/{communitySlug}/flairs/{id}":                                                 "",
+		"DELETE /v1/communities/{communitySlug}/flairs/{id}":                                                "",
+		"POST /v1/communities/{communitySlug}/invites/":                                                     "",
+		"GET /v1/communities/{communitySlug}/invites/":                                                      "",
+		"DELETE /v1/communities/{communitySlug}/invites/{id}":                                               "",
+		"POST /v1/communities/{communitySlug}/join":                                                         "",
+		"GET /v1/communities/{communitySlug}/join-requests/":                                                "",
+		"POST /v1/communities/{communitySlug}/join-requests/{id}/approve":                                   "",
+		"POST /v1/communities/{communitySlug}/join-requests/{id}/deny":                                      "",
+		"DELETE /v1/communities/{communitySlug}/leave":                                                      "",
+		"GET /v1/communities/{communitySlug}/members/":                                                      scopeRead,
+		"PATCH /v1/communities/{communitySlug}/members/{username}":                                          "",
+		"DELETE /v1/communities/{communitySlug}/members/{username}":                                         "",
+		"PUT /v1/communities/{communitySlug}/members/{username}/flair":                                      "",
+		"GET /v1/communities/{communitySlug}/modlog":                                                        "",
+		"GET /v1/communities/{communitySlug}/modqueue/":                                                     "",
+		"POST /v1/communities/{communitySlug}/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/approve": "",
+		"POST /v1/communities/{communitySlug}/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/remove":  "",
+		"POST /v1/communities/{communitySlug}/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/remove-and-ban": "",
+		"GET /v1/communities/{communitySlug}/posts/":                                                               scopeRead,
+		"POST /v1/communities/{communitySlug}/posts/":                                                              scopePostsWrite,
+		"PATCH /v1/communities/{communitySlug}/posts/{postSlug}/":                                                  scopePostsWrite,
+		"GET /v1/communities/{communitySlug}/posts/{postSlug}/":                                                    scopeRead,
+		"DELETE /v1/communities/{communitySlug}/posts/{postSlug}/":                                                 scopePostsWrite,
+		"GET /v1/communities/{communitySlug}/posts/{postSlug}/comments/":                                           scopeRead,
+		"POST /v1/communities/{communitySlug}/posts/{postSlug}/comments/":                                          scopeCommentsWrite,
+		"PATCH /v1/communities/{communitySlug}/posts/{postSlug}/comments/{id}/":                                    scopeCommentsWrite,
+		"DELETE /v1/communities/{communitySlug}/posts/{postSlug}/comments/{id}/":                                   scopeCommentsWrite,
+		"POST /v1/communities/{communitySlug}/posts/{postSlug}/comments/{id}/report":                               "",
+		"GET /v1/communities/{communitySlug}/posts/{postSlug}/comments/{id}/revisions":                             "",
+		"PUT /v1/communities/{communitySlug}/posts/{postSlug}/comments/{id}/vote":                                  scopeCommentsWrite,
+		"PUT /v1/communities/{communitySlug}/posts/{postSlug}/lock":                                                "",
+		"DELETE /v1/communities/{communitySlug}/posts/{postSlug}/lock":                                             "",
+		"GET /v1/communities/{communitySlug}/posts/{postSlug}/media/":                                              scopeRead,
+		"POST /v1/communities/{communitySlug}/posts/{postSlug}/media/":                                             scopePostsWrite,
+		"PUT /v1/communities/{communitySlug}/posts/{postSlug}/media/order":                                         scopePostsWrite,
+		"PATCH /v1/communities/{communitySlug}/posts/{postSlug}/media/{mediaID}":                                   scopePostsWrite,
+		"DELETE /v1/communities/{communitySlug}/posts/{postSlug}/media/{mediaID}":                                  scopePostsWrite,
+		"DELETE /v1/communities/{communitySlug}/posts/{postSlug}/pin":                                              "",
+		"PUT /v1/communities/{communitySlug}/posts/{postSlug}/pin":                                                 "",
+		"POST /v1/communities/{communitySlug}/posts/{postSlug}/report":                                             "",
+		"GET /v1/communities/{communitySlug}/posts/{postSlug}/revisions":                                           "",
+		"PUT /v1/communities/{communitySlug}/posts/{postSlug}/vote":                                                scopePostsWrite,
+		"GET /v1/communities/{communitySlug}/rules":                                                                scopeRead,
+		"PUT /v1/communities/{communitySlug}/rules":                                                                "",
+		"GET /v1/communities/{communitySlug}/transfer/":                                                            "",
+		"POST /v1/communities/{communitySlug}/transfer/":                                                           "",
+		"DELETE /v1/communities/{communitySlug}/transfer/":                                                         "",
+		"POST /v1/communities/{communitySlug}/transfer/accept":                                                     "",
+		"POST /v1/communities/{communitySlug}/transfer/force":                                                      "",
+		"GET /v1/health":    "",
+		"GET /v1/modqueue/": "",
+		"POST /v1/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/approve":        "",
+		"POST /v1/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/remove":         "",
+		"POST /v1/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/remove-and-ban": "",
+		"POST /v1/modqueue/{targetType:post|comment}/{targetID:[0-9]+}/restore":        "",
+		"GET /v1/posts/":                       scopeRead,
+		"PUT /v1/users/activate/{token}":       "",
+		"PUT /v1/users/email/{token}":          "",
+		"PATCH /v1/users/me/":                  "",
+		"GET /v1/users/me/":                    scopeRead,
+		"DELETE /v1/users/me/":                 "",
+		"GET /v1/users/me/communities":         scopeRead,
+		"GET /v1/users/me/drafts":              scopeRead,
+		"POST /v1/users/me/email":              "",
+		"GET /v1/users/me/feed":                scopeRead,
+		"POST /v1/users/me/mfa/totp":           "",
+		"DELETE /v1/users/me/mfa/totp":         "",
+		"POST /v1/users/me/mfa/totp/confirm":   "",
+		"PUT /v1/users/me/password":            "",
+		"GET /v1/users/me/sessions":            "",
+		"DELETE /v1/users/me/sessions":         "",
+		"DELETE /v1/users/me/sessions/{id}":    "",
+		"GET /v1/users/me/tokens":              "",
+		"POST /v1/users/me/tokens":             "",
+		"DELETE /v1/users/me/tokens/{id}":      "",
+		"GET /v1/users/{username}/":            scopeRead,
+		"DELETE /v1/users/{username}/sessions": "",
+	}
+
+	param := regexp.MustCompile(`\{[^}]+\}`)
+	app := newTestApplication(t)
+
+	err := chi.Walk(app.mount().(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+
+		expected, listed := scopes[key]
+		if !listed {
+			t.Errorf("%s: route is missing from the token scope list", key)
+			return nil
+		}
+
+		req, err := http.NewRequest(method, param.ReplaceAllString(route, "1"), nil)
+		if err != nil {
+			return err
+		}
+
+		scope, ok := requiredTokenScope(req)
+		if scope != expected || ok != (expected != "") {
+			t.Errorf("%s: expected scope %q, got (%q, %v)", key, expected, scope, ok)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+var (
+	transferExp = time.Hour * 24 * 7
+
+	errNotCommunityOwner = fmt.Errorf("only the community owner can do this")
+	errOwnerLeave        = fmt.Errorf("transfer ownership of the community before leaving it")
+	errTransferToAdmin   = fmt.Errorf("ownership can only be transferred to another admin")
+)
+
+type TransferPayload struct {
+	Username string `json:"username" validate:"required,max=100"`
+}
+
+func (app *application) getTransferHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+
+	transfer, err := app.store.Transfers.Get(r.Context(), community.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	transfer.ToUser.AvatarURL = app.generateAssetURL(transfer.ToUser.AvatarID, "avatars")
+
+	if err = jsonResponse(w, http.StatusOK, transfer); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) nominateTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TransferPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	if community.UserID != user.ID {
+		app.specificForbiddenResponse(w, r, errNotCommunityOwner)
+		return
+	}
+
+	member, err := app.store.Members.GetByUsername(ctx, community.ID, payload.Username)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if member.User.ID == user.ID || member.Role.Name != "admin" {
+		app.badRequestResponse(w, r, errTransferToAdmin)
+		return
+	}
+
+	transfer := &store.Transfer{
+		CommunityID: community.ID,
+		FromUserID:  user.ID,
+		ToUser:      member.User,
+	}
+
+	if err = app.store.Transfers.Create(ctx, transfer, transferExp); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	transfer.ToUser.AvatarURL = app.generateAssetURL(transfer.ToUser.AvatarID, "avatars")
+
+	if err = jsonResponse(w, http.StatusCreated, transfer); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) cancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	if community.UserID != user.ID {
+		app.specificForbiddenResponse(w, r, errNotCommunityOwner)
+		return
+	}
+
+	if err := app.store.Transfers.Delete(r.Context(), community.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) acceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	community := getCommunityFromContext(r)
+	user := getUserFromContext(r)
+
+	if err := app.store.Transfers.Accept(r.Context(), community.ID, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) forceTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TransferPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	community := getCommunityFromContext(r)
+	ctx := r.Context()
+
+	member, err := app.store.Members.GetByUsername(ctx, community.ID, payload.Username)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	entry := modLogEntry(r, store.ModLogEntry{
+		CommunityID: &community.ID,
+		Action:      store.ModActionForceTransfer,
+		TargetType:  "user",
+		TargetID:    &member.User.ID,
+		TargetLabel: member.User.Username,
+	})
+
+	if err = app.store.Transfers.Force(ctx, community.ID, member.User.ID, entry); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
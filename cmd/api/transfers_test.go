@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/skiba-mateusz/communiverse/internal/store"
+)
+
+type mockTransferStore struct {
+	store.TransferStore
+	created  *store.Transfer
+	deleted  bool
+	acceptTo int64
+	forcedTo int64
+	entry    *store.ModLogEntry
+}
+
+func (m *mockTransferStore) Create(ctx context.Context, transfer *store.Transfer, exp time.Duration) error {
+	m.created = transfer
+	return nil
+}
+
+func (m *mockTransferStore) Delete(ctx context.Context, communityID int64) error {
+	m.deleted = true
+	return nil
+}
+
+// Accept only succeeds for the nominated user, like the store's conditional
+// update.
+func (m *mockTransferStore) Accept(ctx context.Context, communityID, userID int64) error {
+	if userID != m.acceptTo {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (m *mockTransferStore) Force(ctx context.Context, communityID, userID int64, entry *store.ModLogEntry) error {
+	m.forcedTo = userID
+	m.entry = entry
+	return nil
+}
+
+func newTransferRequest(userID int64, body string) *http.Request {
+	community := &store.CommunityDetails{}
+	community.ID = 1
+	community.UserID = 1
+	user := &store.UserDetails{}
+	user.ID = userID
+
+	return withBody(newCommunityRequest(http.MethodPost, community, user, nil), body)
+}
+
+func TestNominateTransfer(t *testing.T) {
+	members := map[string]*store.Member{
+		"owner":  newTestMember(1, "owner", "admin"),
+		"admin":  newTestMember(2, "admin", "admin"),
+		"member": newTestMember(3, "member", "member"),
+	}
+
+	tests := []struct {
+		name     string
+		userID   int64
+		username string
+		expected int
+	}{
+		{"should nominate an admin", 1, "admin", http.StatusCreated},
+		{"should only let the owner nominate", 2, "admin", http.StatusForbidden},
+		{"should not nominate a member", 1, "member", http.StatusBadRequest},
+		{"should not nominate the owner", 1, "owner", http.StatusBadRequest},
+		{"should not nominate an outsider", 1, "nobody", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			transfers := &mockTransferStore{}
+			app.store.Transfers = transfers
+			app.store.Members = &mockMemberStore{members: members}
+
+			req := newTransferRequest(tt.userID, `{"username": "`+tt.username+`"}`)
+			rr := httptest.NewRecorder()
+
+			app.nominateTransferHandler(rr, req)
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if wantCreated := tt.expected == http.StatusCreated; (transfers.created != nil) != wantCreated {
+				t.Errorf("expected created to be %t", wantCreated)
+			}
+		})
+	}
+}
+
+func TestCancelTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int64
+		expected int
+	}{
+		{"should let the owner cancel", 1, http.StatusNoContent},
+		{"should only let the owner cancel", 2, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			transfers := &mockTransferStore{}
+			app.store.Transfers = transfers
+
+			rr := httptest.NewRecorder()
+
+			app.cancelTransferHandler(rr, newTransferRequest(tt.userID, ""))
+
+			checResponseCode(t, tt.expected, rr.Code)
+
+			if wantDeleted := tt.expected == http.StatusNoContent; transfers.deleted != wantDeleted {
+				t.Errorf("expected deleted to be %t", wantDeleted)
+			}
+		})
+	}
+}
+
+func TestAcceptTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int64
+		expected int
+	}{
+		{"should let the nominee accept", 2, http.StatusNoContent},
+		{"should not let anyone else accept", 3, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.store.Transfers = &mockTransferStore{acceptTo: 2}
+
+			rr := httptest.NewRecorder()
+
+			app.acceptTransferHandler(rr, newTransferRequest(tt.userID, ""))
+
+			checResponseCode(t, tt.expected, rr.Code)
+		})
+	}
+}
+
+func TestForceTransfer(t *testing.T) {
+	members := map[string]*store.Member{
+		"member": newTestMember(3, "member", "member"),
+	}
+
+	t.Run("should force ownership onto a member", func(t *testing.T) {
+		app := newTestApplication(t)
+		transfers := &mockTransferStore{}
+		app.store.Transfers = transfers
+		app.store.Members = &mockMemberStore{members: members}
+
+		rr := httptest.NewRecorder()
+
+		app.forceTransferHandler(rr, newTransferRequest(5, `{"username": "member"}`))
+
+		checResponseCode(t, http.StatusNoContent, rr.Code)
+
+		if transfers.forcedTo != 3 {
+			t.Errorf("expected ownership to go to 3, got %d", transfers.forcedTo)
+		}
+
+		entry := transfers.entry
+		if entry == nil || entry.Action != store.ModActionForceTransfer || *entry.TargetID != 3 || *entry.ActorID != 5 {
+			t.Errorf("expected a force transfer entry by the staff member, got %+v", entry)
+		}
+	})
+
+	t.Run("should not force ownership onto an outsider", func(t *testing.T) {
+		app := newTestApplication(t)
+		transfers := &mockTransferStore{}
+		app.store.Transfers = transfers
+		app.store.Members = &mockMemberStore{members: members}
+
+		rr := httptest.NewRecorder()
+
+		app.forceTransferHandler(rr, newTransferRequest(5, `{"username": "nobody"}`))
+
+		checResponseCode(t, http.StatusNotFound, rr.Code)
+
+		if transfers.forcedTo != 0 {
+			t.Error("expected ownership to stay")
+		}
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"image"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/skiba-mateusz/communiverse/internal/mailer"
 	"github.com/skiba-mateusz/communiverse/internal/store"
 	"github.com/skiba-mateusz/communiverse/internal/uploader"
 )
@@ -60,6 +62,51 @@ func (app *application) getCurrentUserFeedHandler(w http.ResponseWriter, r *http
 	}
 }
 
+// getCurrentUserDraftsHandler lists the current user's draft and scheduled
+// posts, newest first by default.
+func (app *application) getCurrentUserDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	query := store.PaginatedPostsQuery{
+		Limit:  10,
+		Offset: 0,
+		Time:   "all-time",
+		View:   "latest",
+		Sort:   "desc",
+	}
+
+	query, err := query.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	posts, meta, err := app.store.Posts.GetUserDrafts(r.Context(), user.ID, query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range posts {
+		posts[i].User.AvatarURL = app.generateAssetURL(posts[i].User.AvatarID, "avatars")
+		posts[i].Community.ThumbnailURL = app.generateAssetURL(posts[i].Community.ThumbnailID, "thumbnails")
+	}
+
+	response := PaginatedPostsResponse{
+		Items: posts,
+		Meta:  meta,
+	}
+
+	if err = jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
 func (app *application) getCurrentUserCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
 	query := store.PaginatedCommunitiesQuery{
 		Limit:  10,
@@ -210,6 +257,156 @@ func (app *application) updateCurrentUserHandler(w http.ResponseWriter, r *http.
 	}
 }
 
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=100"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6,max=100"`
+}
+
+func (app *application) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ChangePasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	session := getSessionFromContext(r)
+
+	if ok := app.verifyCurrentPassword(w, r, user, payload.CurrentPassword); !ok {
+		return
+	}
+
+	if err := user.Password.Set(payload.NewPassword); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.Users.ChangePassword(r.Context(), user.ID, session.ID, user.Password.Hash); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ChangeEmailPayload struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,max=100"`
+}
+
+func (app *application) changeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ChangeEmailPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	session := getSessionFromContext(r)
+	ctx := r.Context()
+
+	if strings.EqualFold(payload.Email, user.Email) {
+		app.badRequestResponse(w, r, fmt.Errorf("new email must be different from the current one"))
+		return
+	}
+
+	if ok := app.verifyCurrentPassword(w, r, user, payload.Password); !ok {
+		return
+	}
+
+	if _, err := app.store.Users.GetByEmail(ctx, payload.Email); err != store.ErrNotFound {
+		switch err {
+		case nil:
+			app.badRequestResponse(w, r, store.ErrDuplicateEmail)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	retryAfter, err := app.throttleEmail(ctx, "change-email", payload.Email, getClientIP(r))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return
+	}
+
+	plainToken, hashToken := generateTokenAndHash()
+
+	if err = app.store.Users.CreateEmailChange(ctx, hashToken, app.config.mail.exp, user.ID, session.ID, payload.Email); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	isProd := app.config.env == "production"
+
+	confirmVars := struct {
+		Username   string
+		ConfirmURL string
+	}{
+		Username:   user.Username,
+		ConfirmURL: fmt.Sprintf("%s/auth/confirm-email/%s", app.config.frontendURL, plainToken),
+	}
+
+	statusCode, err := app.mailer.Send(mailer.ConfirmEmailChangeTemplate, user.Username, payload.Email, confirmVars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending email change confirmation", "error", err)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("email change confirmation sent", "status code", statusCode)
+
+	noticeVars := struct {
+		Username string
+		NewEmail string
+	}{
+		Username: user.Username,
+		NewEmail: payload.Email,
+	}
+
+	statusCode, err = app.mailer.Send(mailer.EmailChangeNoticeTemplate, user.Username, user.Email, noticeVars, !isProd)
+	if err != nil {
+		app.logger.Errorw("error sending email change notice", "error", err)
+	} else {
+		app.logger.Infow("email change notice sent", "status code", statusCode)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) confirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	if err := app.store.Users.ConfirmEmailChange(r.Context(), token); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, err)
+		case store.ErrDuplicateEmail:
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
 
@@ -250,6 +447,39 @@ func (app *application) generateAssetURL(id, assetType string) string {
 	return fmt.Sprintf("%s/%s/%s", app.config.upload.cloudFrontURL, assetType, id)
 }
 
+func (app *application) verifyCurrentPassword(w http.ResponseWriter, r *http.Request, user *store.UserDetails, password string) bool {
+	ctx := r.Context()
+	ip := getClientIP(r)
+	accountKey := passwordThrottleKey(user.ID)
+
+	retryAfter, err := app.loginRetryAfter(ctx, accountKey, ip)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if retryAfter > 0 {
+		app.rateLimitExceededResponse(w, r, retryAfter)
+		return false
+	}
+
+	details, err := app.store.Users.GetByEmail(ctx, user.Email)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if !details.Password.Matches(password) {
+		app.loginFailed(ctx, accountKey, ip, user)
+		app.badRequestResponse(w, r, fmt.Errorf("current password is incorrect"))
+		return false
+	}
+
+	app.loginSucceeded(ctx, accountKey)
+
+	return true
+}
+
 func getUserFromContext(r *http.Request) *store.UserDetails {
 	user := r.Context().Value(userCtx).(*store.UserDetails)
 	return user
@@ -0,0 +1,4 @@
+ALTER TABLE sessions
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '',
+ADD COLUMN last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
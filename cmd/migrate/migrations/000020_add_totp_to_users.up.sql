@@ -0,0 +1,11 @@
+ALTER TABLE users
+ADD COLUMN totp_secret VARCHAR(64),
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code bytea NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider VARCHAR(100) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS auth_attempts (
+    key VARCHAR(255) PRIMARY KEY,
+    failures int NOT NULL DEFAULT 0,
+    blocked_until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_updated_at ON auth_attempts (updated_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    token bytea PRIMARY KEY,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_id int REFERENCES sessions (id) ON DELETE SET NULL,
+    email citext NOT NULL,
+    expiry TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes (user_id);
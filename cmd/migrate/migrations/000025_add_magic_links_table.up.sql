@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS magic_links (
+    token bytea PRIMARY KEY,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expiry TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_magic_links_user_id ON magic_links (user_id);
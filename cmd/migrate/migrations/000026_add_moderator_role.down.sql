@@ -0,0 +1,7 @@
+UPDATE user_communities
+SET role_id = (SELECT id FROM roles WHERE name = 'member')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'moderator');
+
+DELETE FROM roles WHERE name = 'moderator';
+
+UPDATE roles SET level = level - 1 WHERE level >= 4;
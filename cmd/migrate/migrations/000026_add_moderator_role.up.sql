@@ -0,0 +1,4 @@
+UPDATE roles SET level = level + 1 WHERE level >= 3;
+
+INSERT INTO roles (name, level)
+VALUES ('moderator', 3);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS community_bans;
\ No newline at end of file
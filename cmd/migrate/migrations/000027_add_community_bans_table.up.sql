@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS community_bans (
+    id SERIAL PRIMARY KEY,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    moderator_id int REFERENCES users (id) ON DELETE SET NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('ban', 'mute')),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    expiry TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (community_id, user_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_community_bans_user_id ON community_bans (user_id);
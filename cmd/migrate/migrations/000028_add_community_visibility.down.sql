@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS community_invites;
+
+DROP TABLE IF EXISTS community_join_requests;
+
+ALTER TABLE communities
+DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,23 @@
+ALTER TABLE communities
+ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'restricted', 'private'));
+
+CREATE TABLE IF NOT EXISTS community_join_requests (
+    id SERIAL PRIMARY KEY,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (community_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS community_invites (
+    id SERIAL PRIMARY KEY,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    user_id int REFERENCES users (id) ON DELETE SET NULL,
+    token bytea UNIQUE NOT NULL,
+    max_uses int,
+    uses int NOT NULL DEFAULT 0,
+    expiry TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_community_invites_community_id ON community_invites (community_id);
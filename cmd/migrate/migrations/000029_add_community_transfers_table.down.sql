@@ -0,0 +1 @@
+DROP TABLE IF EXISTS community_transfers;
\ No newline at end of file
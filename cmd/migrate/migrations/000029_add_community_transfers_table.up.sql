@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS community_transfers (
+    community_id int PRIMARY KEY REFERENCES communities (id) ON DELETE CASCADE,
+    from_user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expiry TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
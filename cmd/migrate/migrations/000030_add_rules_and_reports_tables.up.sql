@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS community_rules (
+    id SERIAL PRIMARY KEY,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    position int NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_community_rules_community_id ON community_rules (community_id);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    target_id int NOT NULL,
+    rule_id int REFERENCES community_rules (id) ON DELETE SET NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    details VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (reporter_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports (target_type, target_id);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_reports_unresolved;
+
+ALTER TABLE reports
+    DROP COLUMN IF EXISTS resolution,
+    DROP COLUMN IF EXISTS resolved_by,
+    DROP COLUMN IF EXISTS resolved_at;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS removed_by,
+    DROP COLUMN IF EXISTS removed_at;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS removed_by,
+    DROP COLUMN IF EXISTS removed_at;
\ No newline at end of file
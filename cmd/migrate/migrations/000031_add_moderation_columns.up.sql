@@ -0,0 +1,14 @@
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS removed_by int REFERENCES users (id) ON DELETE SET NULL;
+
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS removed_by int REFERENCES users (id) ON DELETE SET NULL;
+
+ALTER TABLE reports
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS resolved_by int REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS resolution VARCHAR(20) CHECK (resolution IN ('approved', 'removed'));
+
+CREATE INDEX IF NOT EXISTS idx_reports_unresolved ON reports (community_id) WHERE resolved_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS public_modlog;
+
+DROP TABLE IF EXISTS mod_log;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS mod_log (
+    id BIGSERIAL PRIMARY KEY,
+    community_id int REFERENCES communities (id) ON DELETE SET NULL,
+    actor_id int REFERENCES users (id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id int,
+    target_label VARCHAR(255) NOT NULL DEFAULT '',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mod_log_community_id ON mod_log (community_id, created_at DESC);
+
+ALTER TABLE communities ADD COLUMN IF NOT EXISTS public_modlog BOOLEAN NOT NULL DEFAULT FALSE;
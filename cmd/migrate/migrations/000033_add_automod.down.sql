@@ -0,0 +1,9 @@
+DELETE FROM users WHERE username = 'automoderator';
+
+ALTER TABLE mod_log DROP COLUMN IF EXISTS automated;
+
+DELETE FROM reports WHERE reporter_id IS NULL;
+
+ALTER TABLE reports ALTER COLUMN reporter_id SET NOT NULL;
+
+DROP TABLE IF EXISTS automod_configs;
\ No newline at end of file
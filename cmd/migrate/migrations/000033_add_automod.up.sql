@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS automod_configs (
+    community_id int PRIMARY KEY REFERENCES communities (id) ON DELETE CASCADE,
+    rules JSONB NOT NULL DEFAULT '[]',
+    updated_by int REFERENCES users (id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE reports ALTER COLUMN reporter_id DROP NOT NULL;
+
+ALTER TABLE mod_log ADD COLUMN IF NOT EXISTS automated BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO users (username, email, password, name, is_active, role_id)
+VALUES ('automoderator', 'automoderator@communiverse.invalid', '', 'AutoModerator', TRUE, (SELECT id FROM roles WHERE name = 'user'))
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_pinned;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_at;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS locked;
\ No newline at end of file
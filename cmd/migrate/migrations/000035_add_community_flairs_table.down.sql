@@ -0,0 +1,5 @@
+ALTER TABLE user_communities DROP COLUMN IF EXISTS flair_id;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS flair_id;
+
+DROP TABLE IF EXISTS community_flairs;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS community_flairs (
+    id SERIAL PRIMARY KEY,
+    community_id int NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('post', 'user')),
+    label VARCHAR(50) NOT NULL,
+    text_color VARCHAR(7) NOT NULL DEFAULT '#000000',
+    background_color VARCHAR(7) NOT NULL DEFAULT '#e5e7eb',
+    admin_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (community_id, type, label)
+);
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS flair_id int REFERENCES community_flairs (id) ON DELETE SET NULL;
+
+ALTER TABLE user_communities ADD COLUMN IF NOT EXISTS flair_id int REFERENCES community_flairs (id) ON DELETE SET NULL;
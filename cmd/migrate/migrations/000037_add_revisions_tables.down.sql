@@ -0,0 +1,7 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS edited_at;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS edited_at;
+
+DROP TABLE IF EXISTS comment_revisions;
+
+DROP TABLE IF EXISTS post_revisions;
\ No newline at end of file
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    post_id int NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    editor_id int REFERENCES users (id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    tags VARCHAR(100)[],
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_revisions_post_id ON post_revisions (post_id, created_at);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id int NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    editor_id int REFERENCES users (id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment_id ON comment_revisions (comment_id, created_at);
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE;
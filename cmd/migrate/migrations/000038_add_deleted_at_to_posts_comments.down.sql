@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_comments_parent_id;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_html TEXT;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT;
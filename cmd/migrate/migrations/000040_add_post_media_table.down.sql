@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_media;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS post_media (
+    id BIGSERIAL PRIMARY KEY,
+    post_id int NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    file_id VARCHAR(36) NOT NULL,
+    position int NOT NULL,
+    caption VARCHAR(300) NOT NULL DEFAULT '',
+    alt_text VARCHAR(300) NOT NULL DEFAULT '',
+    width int NOT NULL,
+    height int NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_media_post_id ON post_media (post_id, position);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rotated_session_tokens;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS rotated_session_tokens (
+    token bytea PRIMARY KEY,
+    session_id int NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    rotated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rotated_session_tokens_session_id ON rotated_session_tokens (session_id);
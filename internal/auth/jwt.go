@@ -2,28 +2,65 @@ package auth
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTAuthenticator struct {
-	secret string
-	aud    string
-	iss    string
+	keys      map[string]SigningKey
+	activeKID string
+	aud       string
+	iss       string
 }
 
 func NewJWTAuthenticator(secret, iss, aud string) *JWTAuthenticator {
+	key, _ := NewSigningKey("", []byte(secret), time.Time{})
+
 	return &JWTAuthenticator{
-		secret: secret,
-		iss:    iss,
-		aud:    aud,
+		keys:      map[string]SigningKey{"": key},
+		activeKID: "",
+		iss:       iss,
+		aud:       aud,
 	}
 }
 
+func NewJWTAuthenticatorWithKeys(keys []SigningKey, activeKID, iss, aud string) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{
+		keys:      make(map[string]SigningKey),
+		activeKID: activeKID,
+		iss:       iss,
+		aud:       aud,
+	}
+
+	for _, key := range keys {
+		if _, ok := a.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key id: %s", key.ID)
+		}
+		a.keys[key.ID] = key
+	}
+
+	active, ok := a.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeKID)
+	}
+
+	if active.expired(time.Now()) {
+		return nil, fmt.Errorf("active signing key %q has expired", activeKID)
+	}
+
+	return a, nil
+}
+
 func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := a.keys[a.activeKID]
 
-	tokenStr, err := token.SignedString([]byte(a.secret))
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	tokenStr, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", err
 	}
@@ -33,14 +70,51 @@ func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
 
 func (a *JWTAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
 	return jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := t.Header["kid"].(string)
+
+		key, ok := a.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+
+		if key.expired(time.Now()) {
+			return nil, fmt.Errorf("signing key %q has expired", kid)
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(a.secret), nil
+
+		return key.PublicKey, nil
 	},
 		jwt.WithExpirationRequired(),
 		jwt.WithAudience(a.aud),
-		jwt.WithIssuer(a.aud),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithIssuer(a.iss),
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Name,
+			jwt.SigningMethodRS256.Name,
+			jwt.SigningMethodES256.Name,
+			jwt.SigningMethodEdDSA.Alg(),
+		}),
 	)
 }
+
+func (a *JWTAuthenticator) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	now := time.Now()
+
+	for _, key := range a.keys {
+		if key.Method == jwt.SigningMethodHS256 || key.expired(now) {
+			continue
+		}
+
+		jwk, err := NewJWK(key.ID, key.PublicKey)
+		if err != nil {
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
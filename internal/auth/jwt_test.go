@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": 1,
+		"iss": "test",
+		"aud": "test",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func TestJWTAuthenticatorKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey, err := NewSigningKey("old", rsaKey, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey, err := NewSigningKey("new", edKey, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := NewJWTAuthenticatorWithKeys([]SigningKey{oldKey}, "old", "test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := NewJWTAuthenticatorWithKeys([]SigningKey{oldKey, newKey}, "new", "test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := before.GenerateToken(newTestClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should sign with the active key", func(t *testing.T) {
+		token, err := after.GenerateToken(newTestClaims())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := after.ValidateToken(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "EdDSA" {
+			t.Errorf("expected EdDSA token signed with new key, got %v %v", parsed.Header["kid"], parsed.Method.Alg())
+		}
+	})
+
+	t.Run("should accept tokens signed with retired keys", func(t *testing.T) {
+		if _, err := after.ValidateToken(oldToken); err != nil {
+			t.Errorf("expected token signed with retired key to be valid: %v", err)
+		}
+	})
+
+	t.Run("should reject tokens signed with expired keys", func(t *testing.T) {
+		expiredKey := oldKey
+		expiredKey.ExpiresAt = time.Now().Add(-time.Minute)
+
+		expired, err := NewJWTAuthenticatorWithKeys([]SigningKey{expiredKey, newKey}, "new", "test", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := expired.ValidateToken(oldToken); err == nil {
+			t.Errorf("expected token signed with expired key to be rejected")
+		}
+
+		if n := len(expired.JWKS().Keys); n != 1 {
+			t.Errorf("expected 1 published key, got %d", n)
+		}
+	})
+
+	t.Run("should publish usable public keys", func(t *testing.T) {
+		jwks := after.JWKS()
+		if len(jwks.Keys) != 2 {
+			t.Fatalf("expected 2 published keys, got %d", len(jwks.Keys))
+		}
+
+		for _, jwk := range jwks.Keys {
+			if _, err := jwk.PublicKey(); err != nil {
+				t.Errorf("key %s: %v", jwk.Kid, err)
+			}
+		}
+	})
+
+	t.Run("should not publish shared secrets", func(t *testing.T) {
+		if n := len(NewJWTAuthenticator("secret", "test", "test").JWKS().Keys); n != 0 {
+			t.Errorf("expected no published keys, got %d", n)
+		}
+	})
+}
+
+func writeECKey(t *testing.T, curve elliptic.Curve, pkcs8 bool) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &pem.Block{Type: "EC PRIVATE KEY"}
+	if pkcs8 {
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+	} else {
+		block.Bytes, err = x509.MarshalECPrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err = os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestJWTAuthenticatorES256(t *testing.T) {
+	for _, pkcs8 := range []bool{true, false} {
+		t.Run(fmt.Sprintf("should sign and publish P-256 keys (pkcs8 %v)", pkcs8), func(t *testing.T) {
+			keys, err := ParseSigningKeys("ec=" + writeECKey(t, elliptic.P256(), pkcs8))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a, err := NewJWTAuthenticatorWithKeys(keys, "ec", "test", "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := a.GenerateToken(newTestClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := a.ValidateToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed.Method.Alg() != "ES256" {
+				t.Errorf("expected ES256 token, got %v", parsed.Method.Alg())
+			}
+
+			jwks := a.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Alg != "ES256" {
+				t.Fatalf("expected 1 published ES256 key, got %+v", jwks.Keys)
+			}
+
+			if _, err := jwks.Keys[0].PublicKey(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("should reject keys on other curves at load", func(t *testing.T) {
+		if _, err := ParseSigningKeys("ec=" + writeECKey(t, elliptic.P384(), true)); err == nil {
+			t.Error("expected P-384 key to be rejected")
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey any
+	PublicKey  any
+	ExpiresAt  time.Time
+}
+
+func (k SigningKey) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+func NewSigningKey(id string, privateKey any, expiresAt time.Time) (SigningKey, error) {
+	key := SigningKey{
+		ID:         id,
+		PrivateKey: privateKey,
+		ExpiresAt:  expiresAt,
+	}
+
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = k.Public()
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return SigningKey{}, fmt.Errorf("unsupported ECDSA curve %s, only P-256 is supported", k.Curve.Params().Name)
+		}
+		key.Method = jwt.SigningMethodES256
+		key.PublicKey = &k.PublicKey
+	case []byte:
+		key.Method = jwt.SigningMethodHS256
+		key.PublicKey = k
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported signing key type %T", privateKey)
+	}
+
+	return key, nil
+}
+
+// ParseSigningKeys reads keys from a comma separated list of
+// "kid=path/to/key.pem" entries. A retired key can be given an expiry with
+// an RFC 3339 suffix, e.g. "old=old.pem@2025-01-01T00:00:00Z".
+func ParseSigningKeys(spec string) ([]SigningKey, error) {
+	keys := []SigningKey{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("malformed signing key entry: %q", entry)
+		}
+
+		var expiresAt time.Time
+		if p, exp, ok := strings.Cut(path, "@"); ok {
+			t, err := time.Parse(time.RFC3339, exp)
+			if err != nil {
+				return nil, err
+			}
+			path, expiresAt = p, t
+		}
+
+		privateKey, err := loadPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading signing key %s: %w", kid, err)
+		}
+
+		key, err := NewSigningKey(kid, privateKey, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func loadPrivateKey(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+func NewJWK(kid string, publicKey any) (JWK, error) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodES256.Alg(),
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
@@ -13,6 +13,8 @@ var (
 		"aud": "test-aud",
 		"iss": "test-aud",
 		"sub": int64(42),
+		"sid": int64(1),
+		"typ": "access",
 		"exp": time.Now().Add(time.Hour).Unix(),
 	}
 )
@@ -26,3 +28,7 @@ func (a *TestAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
 		return []byte(secret), nil
 	})
 }
+
+func (a *TestAuthenticator) JWKS() JWKS {
+	return JWKS{Keys: []JWK{}}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+}
+
+type IdentityProvider interface {
+	AuthCodeURL(state, nonce, codeChallenge string) string
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+type OIDCConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OIDCProvider struct {
+	config   OIDCConfig
+	issuer   string
+	authURL  string
+	tokenURL string
+	jwksURL  string
+	client   *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// jwksRefreshInterval limits how often an unknown key id can make us fetch
+// the provider's keys again, so tokens with made up ids can't flood it.
+var jwksRefreshInterval = time.Minute
+
+func NewOIDCProvider(ctx context.Context, config OIDCConfig) (*OIDCProvider, error) {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	p := &OIDCProvider{
+		config: config,
+		client: &http.Client{Timeout: time.Second * 10},
+		keys:   make(map[string]any),
+	}
+
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+
+	discoveryURL := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s failed: %w", config.Name, err)
+	}
+
+	if discovery.Issuer != strings.TrimSuffix(config.IssuerURL, "/") {
+		return nil, fmt.Errorf("oidc issuer mismatch: expected %s, got %s", config.IssuerURL, discovery.Issuer)
+	}
+
+	p.issuer = discovery.Issuer
+	p.authURL = discovery.AuthorizationEndpoint
+	p.tokenURL = discovery.TokenEndpoint
+	p.jwksURL = discovery.JWKSURI
+
+	return p, nil
+}
+
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+
+	return p.authURL + separator + params.Encode()
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", p.config.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange failed with status %d", res.StatusCode)
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&tokenResponse); err != nil {
+		return nil, err
+	}
+
+	if tokenResponse.IDToken == "" {
+		return nil, fmt.Errorf("oidc token response is missing id_token")
+	}
+
+	return p.verifyIDToken(ctx, tokenResponse.IDToken, nonce)
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, idToken, nonce string) (*Identity, error) {
+	token, err := jwt.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	},
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, fmt.Errorf("oidc id token nonce mismatch")
+	}
+
+	identity := &Identity{Provider: p.config.Name}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.Username, _ = claims["preferred_username"].(string)
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("oidc id token is missing subject")
+	}
+
+	return identity, nil
+}
+
+func (p *OIDCProvider) getKey(ctx context.Context, kid string) (any, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	p.mu.Lock()
+	if time.Since(p.fetchedAt) < jwksRefreshInterval {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("oidc signing key %q not found", kid)
+	}
+	p.fetchedAt = time.Now()
+	p.mu.Unlock()
+
+	var jwks JWKS
+	if err := p.getJSON(ctx, p.jwksURL, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = publicKey
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc signing key %q not found", kid)
+	}
+
+	return key, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, data any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	return json.NewDecoder(res.Body).Decode(data)
+}
+
+func GenerateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func CodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
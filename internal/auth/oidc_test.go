@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type mockOIDCServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	code      string
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+	kid       string
+	jwksHits  int
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOIDCServer{key: key, kid: "mock"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.jwksHits++
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{{
+			Kty: "RSA",
+			Kid: "mock",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.FormValue("code") != m.code || CodeChallenge(r.FormValue("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   m.URL,
+			"aud":   "client",
+			"sub":   "external-id",
+			"nonce": m.nonce,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = m.kid
+
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func TestOIDCProvider(t *testing.T) {
+	server := newMockOIDCServer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, OIDCConfig{
+		Name:        "mock",
+		IssuerURL:   server.URL,
+		ClientID:    "client",
+		RedirectURL: "http://localhost/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := GenerateRandomString(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.code = "auth-code"
+	server.challenge = CodeChallenge(verifier)
+	server.nonce = "nonce"
+	server.claims = jwt.MapClaims{"email": "test@example.com", "email_verified": true}
+
+	t.Run("should exchange code for verified identity", func(t *testing.T) {
+		identity, err := provider.Exchange(ctx, "auth-code", verifier, "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if identity.Subject != "external-id" || identity.Email != "test@example.com" || !identity.EmailVerified {
+			t.Errorf("unexpected identity: %+v", identity)
+		}
+	})
+
+	t.Run("should reject invalid code verifier", func(t *testing.T) {
+		if _, err := provider.Exchange(ctx, "auth-code", "wrong-verifier", "nonce"); err == nil {
+			t.Errorf("expected exchange to fail")
+		}
+	})
+
+	t.Run("should reject nonce mismatch", func(t *testing.T) {
+		if _, err := provider.Exchange(ctx, "auth-code", verifier, "other-nonce"); err == nil {
+			t.Errorf("expected exchange to fail")
+		}
+	})
+
+	t.Run("should not refetch keys for every unknown key id", func(t *testing.T) {
+		server.kid = "unknown"
+		defer func() { server.kid = "mock" }()
+
+		hits := server.jwksHits
+		for i := 0; i < 3; i++ {
+			if _, err := provider.Exchange(ctx, "auth-code", verifier, "nonce"); err == nil {
+				t.Fatal("expected exchange to fail")
+			}
+		}
+
+		if server.jwksHits != hits {
+			t.Errorf("expected no refetch within the refresh interval, got %d", server.jwksHits-hits)
+		}
+	})
+}
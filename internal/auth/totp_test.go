@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	t.Run("should generate rfc 6238 codes", func(t *testing.T) {
+		for _, v := range vectors {
+			code, err := TOTPCode(secret, time.Unix(v.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != v.code {
+				t.Errorf("at %d expected code %s, got %s", v.unix, v.code, code)
+			}
+		}
+	})
+
+	t.Run("should accept codes from adjacent time steps", func(t *testing.T) {
+		now := time.Unix(1111111111, 0)
+
+		code, err := TOTPCode(secret, now.Add(-totpPeriod))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		step, ok := ValidateTOTP(secret, code, now)
+		if !ok {
+			t.Fatalf("expected code from previous step to be valid")
+		}
+
+		if want := now.Unix()/int64(totpPeriod.Seconds()) - 1; step != want {
+			t.Errorf("expected step %d, got %d", want, step)
+		}
+	})
+
+	t.Run("should reject stale and malformed codes", func(t *testing.T) {
+		now := time.Unix(1111111111, 0)
+
+		code, err := TOTPCode(secret, now.Add(-5*totpPeriod))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := ValidateTOTP(secret, code, now); ok {
+			t.Errorf("expected stale code to be rejected")
+		}
+
+		if _, ok := ValidateTOTP(secret, "12345", now); ok {
+			t.Errorf("expected short code to be rejected")
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package automod
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Username is the account AutoModerator replies are posted as.
+const Username = "automoderator"
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+
+	ActionRemove = "remove"
+	ActionReport = "report"
+	ActionLock   = "lock"
+	ActionReply  = "reply"
+
+	maxRules         = 50
+	maxNameLength    = 50
+	maxPatternLength = 500
+	maxReplyLength   = 1000
+	maxReasonLength  = 255
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://([^\s/?#:]+)`)
+
+type Rule struct {
+	Name       string     `json:"name"`
+	Targets    []string   `json:"targets,omitempty"`
+	Conditions Conditions `json:"conditions"`
+	Action     string     `json:"action"`
+	Reply      string     `json:"reply,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// Conditions must all hold for a rule to match, unset conditions are ignored.
+type Conditions struct {
+	Title               string   `json:"title,omitempty"`
+	Content             string   `json:"content,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	AccountAgeDaysBelow *int     `json:"accountAgeDaysBelow,omitempty"`
+	KarmaBelow          *int     `json:"karmaBelow,omitempty"`
+	Domains             []string `json:"domains,omitempty"`
+}
+
+// Subject is a post or comment being evaluated along with its author's
+// standing. Comments have no title or tags.
+type Subject struct {
+	Type       string
+	Title      string
+	Content    string
+	Tags       []string
+	AccountAge time.Duration
+	Karma      int
+}
+
+type Ruleset struct {
+	rules    []Rule
+	compiled []compiledRule
+}
+
+type compiledRule struct {
+	title   *regexp.Regexp
+	content *regexp.Regexp
+}
+
+// Parse decodes and validates a JSON array of rules, the error names the
+// offending rule so it can be shown to the community admin as is.
+func Parse(data []byte) (*Ruleset, error) {
+	rules := []Rule{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	return New(rules)
+}
+
+func New(rules []Rule) (*Ruleset, error) {
+	if len(rules) > maxRules {
+		return nil, fmt.Errorf("a community can have at most %d rules", maxRules)
+	}
+
+	rs := &Ruleset{
+		rules:    rules,
+		compiled: make([]compiledRule, len(rules)),
+	}
+
+	for i := range rules {
+		compiled, err := rules[i].compile()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i+1, rules[i].Name, err)
+		}
+		rs.compiled[i] = compiled
+	}
+
+	return rs, nil
+}
+
+func (rs *Ruleset) Rules() []Rule {
+	return rs.rules
+}
+
+// Evaluate returns the rules matching the subject in the order they were
+// defined.
+func (rs *Ruleset) Evaluate(s Subject) []Rule {
+	matched := []Rule{}
+
+	for i, rule := range rs.rules {
+		if rule.matches(rs.compiled[i], s) {
+			matched = append(matched, rule)
+		}
+	}
+
+	return matched
+}
+
+func (r *Rule) compile() (compiledRule, error) {
+	var compiled compiledRule
+
+	if r.Name == "" || len(r.Name) > maxNameLength {
+		return compiled, fmt.Errorf("name is required and must be at most %d characters", maxNameLength)
+	}
+
+	if len(r.Targets) == 0 {
+		r.Targets = []string{TargetPost, TargetComment}
+	}
+	for _, target := range r.Targets {
+		if target != TargetPost && target != TargetComment {
+			return compiled, fmt.Errorf("unknown target %q", target)
+		}
+	}
+
+	switch r.Action {
+	case ActionRemove, ActionReport:
+	case ActionLock:
+		if slices.Contains(r.Targets, TargetComment) {
+			return compiled, fmt.Errorf("only posts can be locked")
+		}
+	case ActionReply:
+		if r.Reply == "" || len(r.Reply) > maxReplyLength {
+			return compiled, fmt.Errorf("reply is required and must be at most %d characters", maxReplyLength)
+		}
+	default:
+		return compiled, fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	if len(r.Reason) > maxReasonLength {
+		return compiled, fmt.Errorf("reason must be at most %d characters", maxReasonLength)
+	}
+
+	c := r.Conditions
+
+	if (c.Title != "" || len(c.Tags) > 0) && slices.Contains(r.Targets, TargetComment) {
+		return compiled, fmt.Errorf("title and tags conditions only apply to posts")
+	}
+
+	if c.Title == "" && c.Content == "" && len(c.Tags) == 0 && c.AccountAgeDaysBelow == nil && c.KarmaBelow == nil && len(c.Domains) == 0 {
+		return compiled, fmt.Errorf("at least one condition is required")
+	}
+
+	var err error
+	if compiled.title, err = compilePattern(c.Title); err != nil {
+		return compiled, fmt.Errorf("title: %w", err)
+	}
+	if compiled.content, err = compilePattern(c.Content); err != nil {
+		return compiled, fmt.Errorf("content: %w", err)
+	}
+
+	for i, domain := range c.Domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if domain == "" || strings.ContainsAny(domain, "/: ") {
+			return compiled, fmt.Errorf("invalid domain %q", c.Domains[i])
+		}
+		r.Conditions.Domains[i] = domain
+	}
+
+	return compiled, nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	if len(pattern) > maxPatternLength {
+		return nil, fmt.Errorf("pattern must be at most %d characters", maxPatternLength)
+	}
+
+	return regexp.Compile(pattern)
+}
+
+func (r Rule) matches(compiled compiledRule, s Subject) bool {
+	if !slices.Contains(r.Targets, s.Type) {
+		return false
+	}
+
+	c := r.Conditions
+
+	if compiled.title != nil && !compiled.title.MatchString(s.Title) {
+		return false
+	}
+
+	if compiled.content != nil && !compiled.content.MatchString(s.Content) {
+		return false
+	}
+
+	if len(c.Tags) > 0 && !slices.ContainsFunc(s.Tags, func(tag string) bool {
+		return slices.ContainsFunc(c.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}) {
+		return false
+	}
+
+	if c.AccountAgeDaysBelow != nil && s.AccountAge >= time.Hour*24*time.Duration(*c.AccountAgeDaysBelow) {
+		return false
+	}
+
+	if c.KarmaBelow != nil && s.Karma >= *c.KarmaBelow {
+		return false
+	}
+
+	if len(c.Domains) > 0 && !linksTo(s.Title+" "+s.Content, c.Domains) {
+		return false
+	}
+
+	return true
+}
+
+// linksTo reports whether the text links to any of the domains or their
+// subdomains.
+func linksTo(text string, domains []string) bool {
+	for _, match := range linkPattern.FindAllStringSubmatch(text, -1) {
+		host := strings.ToLower(match[1])
+
+		for _, domain := range domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package automod
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{"valid", `[{"name": "spam", "conditions": {"content": "(?i)buy now"}, "action": "remove"}]`, ""},
+		{"unknown field", `[{"name": "spam", "conditions": {"body": "x"}, "action": "remove"}]`, "unknown field"},
+		{"missing name", `[{"conditions": {"content": "x"}, "action": "remove"}]`, "name is required"},
+		{"unknown action", `[{"name": "a", "conditions": {"content": "x"}, "action": "explode"}]`, "unknown action"},
+		{"no conditions", `[{"name": "a", "conditions": {}, "action": "report"}]`, "at least one condition"},
+		{"invalid regex", `[{"name": "a", "conditions": {"content": "("}, "action": "report"}]`, "content:"},
+		{"reply without text", `[{"name": "a", "conditions": {"karmaBelow": 1}, "action": "reply"}]`, "reply is required"},
+		{"lock comments", `[{"name": "a", "conditions": {"karmaBelow": 1}, "action": "lock"}]`, "only posts can be locked"},
+		{"title on comments", `[{"name": "a", "targets": ["comment"], "conditions": {"title": "x"}, "action": "report"}]`, "only apply to posts"},
+		{"invalid domain", `[{"name": "a", "conditions": {"domains": ["http://x.com"]}, "action": "report"}]`, "invalid domain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.rules))
+
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rs, err := Parse([]byte(`[
+		{"name": "new accounts", "targets": ["post"], "conditions": {"accountAgeDaysBelow": 7, "karmaBelow": 10}, "action": "report"},
+		{"name": "shorteners", "conditions": {"domains": ["bit.ly"]}, "action": "remove"},
+		{"name": "questions", "targets": ["post"], "conditions": {"title": "\\?$", "tags": ["Help"]}, "action": "reply", "reply": "Check the FAQ first."}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		matched []string
+	}{
+		{
+			"new account",
+			Subject{Type: TargetPost, AccountAge: time.Hour, Karma: 0},
+			[]string{"new accounts"},
+		},
+		{
+			"new account with karma",
+			Subject{Type: TargetPost, AccountAge: time.Hour, Karma: 10},
+			[]string{},
+		},
+		{
+			"new account comment",
+			Subject{Type: TargetComment, AccountAge: time.Hour},
+			[]string{},
+		},
+		{
+			"subdomain link",
+			Subject{Type: TargetComment, Content: "see https://www.Bit.ly/abc", AccountAge: time.Hour * 24 * 30},
+			[]string{"shorteners"},
+		},
+		{
+			"lookalike domain",
+			Subject{Type: TargetComment, Content: "see https://notbit.ly/abc", AccountAge: time.Hour * 24 * 30},
+			[]string{},
+		},
+		{
+			"question with tag",
+			Subject{Type: TargetPost, Title: "How do I start?", Tags: []string{"help"}, AccountAge: time.Hour * 24 * 30},
+			[]string{"questions"},
+		},
+		{
+			"question without tag",
+			Subject{Type: TargetPost, Title: "How do I start?", Tags: []string{"news"}, AccountAge: time.Hour * 24 * 30},
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := rs.Evaluate(tt.subject)
+
+			if len(matched) != len(tt.matched) {
+				t.Fatalf("expected %d matches, got %d", len(tt.matched), len(matched))
+			}
+
+			for i, rule := range matched {
+				if rule.Name != tt.matched[i] {
+					t.Errorf("expected rule %q, got %q", tt.matched[i], rule.Name)
+				}
+			}
+		})
+	}
+}
@@ -267,6 +267,7 @@ func generatePosts(num int, communities []*store.CommunityDetails, users []*stor
 				Slug:        fmt.Sprintf("%s-%d", slug.Make(title), i),
 				UserID:      user.ID,
 				CommunityID: community.ID,
+				Status:      store.PostStatusPublished,
 			},
 		}
 	}
@@ -0,0 +1,145 @@
+package diff
+
+import "unicode"
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Chunk is a run of text that is kept, inserted or deleted going from the old
+// text to the new one.
+type Chunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs two texts word by word, whitespace is kept so joining the equal
+// and deleted chunks gives back the old text, and the equal and inserted ones
+// the new text.
+func Words(from, to string) []Chunk {
+	a, b := tokenize(from), tokenize(to)
+
+	chunks := []Chunk{}
+
+	// Common ends are cheap to strip and usually most of an edited text.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		chunks = appendChunk(chunks, OpEqual, a[prefix])
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	chunks = diff(chunks, a[:len(a)-suffix], b[:len(b)-suffix])
+
+	for _, token := range a[len(a)-suffix:] {
+		chunks = appendChunk(chunks, OpEqual, token)
+	}
+
+	return chunks
+}
+
+// diff appends the chunks turning a into b. It splits a in half and finds
+// where the longest common subsequence crosses b at that point, keeping only
+// two rows of the table in memory instead of all of it (Hirschberg).
+func diff(chunks []Chunk, a, b []string) []Chunk {
+	switch {
+	case len(a) == 0:
+		for _, token := range b {
+			chunks = appendChunk(chunks, OpInsert, token)
+		}
+		return chunks
+	case len(b) == 0:
+		for _, token := range a {
+			chunks = appendChunk(chunks, OpDelete, token)
+		}
+		return chunks
+	case len(a) == 1:
+		for j, token := range b {
+			if token == a[0] {
+				chunks = diff(chunks, nil, b[:j])
+				chunks = appendChunk(chunks, OpEqual, token)
+				return diff(chunks, nil, b[j+1:])
+			}
+		}
+		chunks = appendChunk(chunks, OpDelete, a[0])
+		return diff(chunks, nil, b)
+	}
+
+	mid := len(a) / 2
+	head := lcsLengths(a[:mid], b, false)
+	tail := lcsLengths(a[mid:], b, true)
+
+	split, best := 0, -1
+	for j := range head {
+		if n := head[j] + tail[len(b)-j]; n > best {
+			split, best = j, n
+		}
+	}
+
+	chunks = diff(chunks, a[:mid], b[:split])
+	return diff(chunks, a[mid:], b[split:])
+}
+
+// lcsLengths returns the last row of the LCS table of a and b, the length of
+// the longest common subsequence of a and every prefix of b, or of every
+// suffix when reverse is set.
+func lcsLengths(a, b []string, reverse bool) []int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	at := func(tokens []string, i int) string {
+		if reverse {
+			return tokens[len(tokens)-1-i]
+		}
+		return tokens[i]
+	}
+
+	for i := range a {
+		for j := range b {
+			if at(a, i) == at(b, j) {
+				curr[j+1] = prev[j] + 1
+			} else {
+				curr[j+1] = max(prev[j+1], curr[j])
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev
+}
+
+func appendChunk(chunks []Chunk, op, text string) []Chunk {
+	if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+		chunks[n-1].Text += text
+		return chunks
+	}
+
+	return append(chunks, Chunk{Op: op, Text: text})
+}
+
+// tokenize splits text into alternating runs of whitespace and non-whitespace.
+func tokenize(text string) []string {
+	tokens := []string{}
+
+	start, space := 0, false
+	for i, r := range text {
+		if i > start && unicode.IsSpace(r) != space {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+
+	return tokens
+}
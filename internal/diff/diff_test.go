@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		chunks []Chunk
+	}{
+		{"unchanged", "hello world", "hello world", []Chunk{{OpEqual, "hello world"}}},
+		{"empty", "", "", []Chunk{}},
+		{"added", "", "hello", []Chunk{{OpInsert, "hello"}}},
+		{"removed", "hello", "", []Chunk{{OpDelete, "hello"}}},
+		{"replaced word", "the quick fox", "the slow fox", []Chunk{
+			{OpEqual, "the "},
+			{OpDelete, "quick"},
+			{OpInsert, "slow"},
+			{OpEqual, " fox"},
+		}},
+		{"several edits", "one two three four five", "one 2 three four 5", []Chunk{
+			{OpEqual, "one "},
+			{OpDelete, "two"},
+			{OpInsert, "2"},
+			{OpEqual, " three four "},
+			{OpDelete, "five"},
+			{OpInsert, "5"},
+		}},
+		{"appended", "buy now", "buy now, edited", []Chunk{
+			{OpEqual, "buy "},
+			{OpDelete, "now"},
+			{OpInsert, "now, edited"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Words(tt.from, tt.to)
+
+			if !reflect.DeepEqual(chunks, tt.chunks) {
+				t.Fatalf("expected %v, got %v", tt.chunks, chunks)
+			}
+
+			var from, to string
+			for _, chunk := range chunks {
+				if chunk.Op != OpInsert {
+					from += chunk.Text
+				}
+				if chunk.Op != OpDelete {
+					to += chunk.Text
+				}
+			}
+
+			if from != tt.from || to != tt.to {
+				t.Fatalf("chunks don't rebuild the texts, got %q and %q", from, to)
+			}
+		})
+	}
+}
@@ -3,10 +3,16 @@ package mailer
 import "embed"
 
 var (
-	fromName               = "Communiverse Team"
-	maxRetries             = 3
-	InviteUserTemplate     = "user_invitation.gohtml"
-	ForgotPasswordTemplate = "forgot_password.gohtml"
+	fromName                   = "Communiverse Team"
+	maxRetries                 = 3
+	InviteUserTemplate         = "user_invitation.gohtml"
+	ForgotPasswordTemplate     = "forgot_password.gohtml"
+	AccountLockedTemplate      = "account_locked.gohtml"
+	ConfirmEmailChangeTemplate = "confirm_email_change.gohtml"
+	EmailChangeNoticeTemplate  = "email_change_notice.gohtml"
+	MagicLinkTemplate          = "magic_link.gohtml"
+	CommunityBanTemplate       = "community_ban.gohtml"
+	PostPublishedTemplate      = "post_published.gohtml"
 )
 
 //go:embed "templates"
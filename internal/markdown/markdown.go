@@ -0,0 +1,130 @@
+package markdown
+
+import (
+	"bytes"
+	stdhtml "html"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.Table,
+		extension.Strikethrough,
+		extension.Linkify,
+		spoilerExtension{},
+	),
+	goldmark.WithRendererOptions(
+		html.WithXHTML(),
+	),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^spoiler$`)).OnElements("span")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// Render converts CommonMark, with GFM tables, strikethrough and autolinks and
+// ||spoilers||, to HTML. Raw HTML in the source is dropped and the output is
+// sanitized, so it's safe to embed as is.
+func Render(source string) string {
+	var buf bytes.Buffer
+
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return "<p>" + stdhtml.EscapeString(source) + "</p>"
+	}
+
+	return policy.SanitizeReader(&buf).String()
+}
+
+var kindSpoiler = gast.NewNodeKind("Spoiler")
+
+type spoiler struct {
+	gast.BaseInline
+}
+
+func (n *spoiler) Kind() gast.NodeKind {
+	return kindSpoiler
+}
+
+func (n *spoiler) Dump(source []byte, level int) {
+	gast.DumpHelper(n, source, level, nil, nil)
+}
+
+type spoilerDelimiterProcessor struct{}
+
+func (p spoilerDelimiterProcessor) IsDelimiter(b byte) bool {
+	return b == '|'
+}
+
+func (p spoilerDelimiterProcessor) CanOpenCloser(opener, closer *parser.Delimiter) bool {
+	return opener.Char == closer.Char
+}
+
+func (p spoilerDelimiterProcessor) OnMatch(consumes int) gast.Node {
+	return &spoiler{}
+}
+
+// spoilerParser parses ||text|| the same way GFM parses ~~text~~.
+type spoilerParser struct{}
+
+func (s spoilerParser) Trigger() []byte {
+	return []byte{'|'}
+}
+
+func (s spoilerParser) Parse(parent gast.Node, block text.Reader, pc parser.Context) gast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+
+	node := parser.ScanDelimiter(line, before, 2, spoilerDelimiterProcessor{})
+	if node == nil || node.OriginalLength != 2 || before == '|' {
+		return nil
+	}
+
+	node.Segment = segment.WithStop(segment.Start + node.OriginalLength)
+	block.Advance(node.OriginalLength)
+	pc.PushDelimiter(node)
+
+	return node
+}
+
+func (s spoilerParser) CloseBlock(parent gast.Node, pc parser.Context) {}
+
+type spoilerRenderer struct{}
+
+func (r spoilerRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindSpoiler, func(w util.BufWriter, source []byte, n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(`<span class="spoiler">`)
+		} else {
+			_, _ = w.WriteString("</span>")
+		}
+		return gast.WalkContinue, nil
+	})
+}
+
+type spoilerExtension struct{}
+
+func (e spoilerExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(spoilerParser{}, 500),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(spoilerRenderer{}, 500),
+	))
+}
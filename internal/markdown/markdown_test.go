@@ -0,0 +1,29 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		html   string
+	}{
+		{"paragraph", "hello *world*", "<p>hello <em>world</em></p>\n"},
+		{"strikethrough", "~~gone~~", "<p><del>gone</del></p>\n"},
+		{"spoiler", "the end: ||they win||", "<p>the end: <span class=\"spoiler\">they win</span></p>\n"},
+		{"unclosed spoiler", "a || b", "<p>a || b</p>\n"},
+		{"autolink", "see https://example.com", "<p>see <a href=\"https://example.com\" rel=\"nofollow noopener\" target=\"_blank\">https://example.com</a></p>\n"},
+		{"raw html", "<b onclick=\"steal()\">hi</b>", "<p>hi</p>\n"},
+		{"script", "<script>alert(1)</script>", "\n"},
+		{"javascript link", "[click](javascript:alert(1))", "<p>click</p>\n"},
+		{"table", "| a |\n|---|\n| 1 |", "<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n</tr>\n</tbody>\n</table>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if html := Render(tt.source); html != tt.html {
+				t.Fatalf("expected %q, got %q", tt.html, html)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AuthorStanding is what AutoModerator knows about an author, karma is the
+// sum of votes on all of their posts and comments.
+type AuthorStanding struct {
+	CreatedAt time.Time
+	Karma     int
+}
+
+type AutoModPost struct {
+	ID      int64
+	Title   string
+	Slug    string
+	Content string
+	Tags    []string
+	Author  AuthorStanding
+}
+
+type AutoModStore struct {
+	db *sql.DB
+}
+
+// GetRules returns the raw JSON rules of the community, an empty list when
+// none have been saved.
+func (s *AutoModStore) GetRules(ctx context.Context, communityID int64) (json.RawMessage, error) {
+	query := `SELECT rules FROM automod_configs WHERE community_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var rules json.RawMessage
+
+	err := s.db.QueryRowContext(ctx, query, communityID).Scan(&rules)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return json.RawMessage(`[]`), nil
+		default:
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+func (s *AutoModStore) SaveRules(ctx context.Context, communityID, userID int64, rules json.RawMessage, entry *ModLogEntry) error {
+	query := `
+		INSERT INTO automod_configs (community_id, rules, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (community_id) DO UPDATE SET
+			rules = EXCLUDED.rules,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if _, err := tx.ExecContext(ctx, query, communityID, string(rules), userID); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *AutoModStore) GetAuthorStanding(ctx context.Context, userID int64) (*AuthorStanding, error) {
+	query := `
+		SELECT u.created_at, ` + karmaColumn + `
+		FROM users u
+		WHERE u.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	standing := &AuthorStanding{}
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&standing.CreatedAt,
+		&standing.Karma,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return standing, nil
+}
+
+// GetRecentPosts returns the latest visible posts of a community along with
+// their authors' standing, used to dry-run rules.
+func (s *AutoModStore) GetRecentPosts(ctx context.Context, communityID int64, limit int) ([]AutoModPost, error) {
+	query := `
+		SELECT p.id, p.title, p.slug, p.content, p.tags, u.created_at, ` + karmaColumn + `
+		FROM posts p
+		INNER JOIN users u ON u.id = p.user_id
+		WHERE p.community_id = $1 AND p.removed_at IS NULL AND p.deleted_at IS NULL AND p.status = 'published'
+		ORDER BY p.created_at DESC
+		LIMIT $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	posts := []AutoModPost{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID, limit)
+	if err != nil {
+		return posts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post AutoModPost
+
+		if err = rows.Scan(
+			&post.ID,
+			&post.Title,
+			&post.Slug,
+			&post.Content,
+			pq.Array(&post.Tags),
+			&post.Author.CreatedAt,
+			&post.Author.Karma,
+		); err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+
+	return posts, nil
+}
+
+const karmaColumn = `
+	COALESCE((SELECT SUM(pv.value) FROM post_votes pv INNER JOIN posts vp ON vp.id = pv.post_id WHERE vp.user_id = u.id), 0) +
+	COALESCE((SELECT SUM(cv.value) FROM comment_votes cv INNER JOIN comments vc ON vc.id = cv.comment_id WHERE vc.user_id = u.id), 0)
+	AS karma
+`
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+var ErrBanned = fmt.Errorf("the user is banned from this community")
+
+const (
+	BanTypeBan  = "ban"
+	BanTypeMute = "mute"
+)
+
+type Ban struct {
+	ID          int64        `json:"id"`
+	CommunityID int64        `json:"communityID"`
+	User        UserOverview `json:"user"`
+	ModeratorID *int64       `json:"moderatorID"`
+	Type        string       `json:"type"`
+	Reason      string       `json:"reason"`
+	Expiry      *string      `json:"expiry"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+type BanStore struct {
+	db *sql.DB
+}
+
+func (s *BanStore) Create(ctx context.Context, ban *Ban, exp *time.Duration, entry *ModLogEntry) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := createBan(ctx, tx, ban, exp); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// createBan bans or mutes the user, a ban also takes them out of the
+// community.
+func createBan(ctx context.Context, tx *sql.Tx, ban *Ban, exp *time.Duration) error {
+	query := `
+		INSERT INTO community_bans (community_id, user_id, moderator_id, type, reason, expiry)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (community_id, user_id, type) DO UPDATE SET
+			moderator_id = EXCLUDED.moderator_id,
+			reason = EXCLUDED.reason,
+			expiry = EXCLUDED.expiry,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING id, expiry, created_at
+	`
+	leaveQuery := `DELETE FROM user_communities WHERE community_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var expiry *time.Time
+	if exp != nil {
+		t := time.Now().Add(*exp)
+		expiry = &t
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		ban.CommunityID,
+		ban.User.ID,
+		ban.ModeratorID,
+		ban.Type,
+		ban.Reason,
+		expiry,
+	).Scan(
+		&ban.ID,
+		&ban.Expiry,
+		&ban.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ban.Type != BanTypeBan {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, leaveQuery, ban.CommunityID, ban.User.ID)
+	return err
+}
+
+// GetActive returns the most restrictive ban currently applying to the user,
+// a ban takes precedence over a mute.
+func (s *BanStore) GetActive(ctx context.Context, communityID, userID int64) (*Ban, error) {
+	query := `
+		SELECT
+			b.id, b.community_id, b.moderator_id, b.type, b.reason, b.expiry, b.created_at,
+			u.id, u.name, u.username, u.avatar_id
+		FROM community_bans b
+		INNER JOIN users u ON u.id = b.user_id
+		WHERE b.community_id = $1 AND b.user_id = $2 AND (b.expiry IS NULL OR b.expiry > $3)
+		ORDER BY b.type = 'ban' DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	ban := &Ban{}
+	err := s.db.QueryRowContext(ctx, query, communityID, userID, time.Now()).Scan(
+		&ban.ID,
+		&ban.CommunityID,
+		&ban.ModeratorID,
+		&ban.Type,
+		&ban.Reason,
+		&ban.Expiry,
+		&ban.CreatedAt,
+		&ban.User.ID,
+		&ban.User.Name,
+		&ban.User.Username,
+		&ban.User.AvatarID,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return ban, nil
+}
+
+func (s *BanStore) GetCommunityBans(ctx context.Context, communityID int64) ([]Ban, error) {
+	query := `
+		SELECT
+			b.id, b.community_id, b.moderator_id, b.type, b.reason, b.expiry, b.created_at,
+			u.id, u.name, u.username, u.avatar_id
+		FROM community_bans b
+		INNER JOIN users u ON u.id = b.user_id
+		WHERE b.community_id = $1 AND (b.expiry IS NULL OR b.expiry > $2)
+		ORDER BY b.created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	bans := []Ban{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID, time.Now())
+	if err != nil {
+		return bans, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ban Ban
+
+		if err := rows.Scan(
+			&ban.ID,
+			&ban.CommunityID,
+			&ban.ModeratorID,
+			&ban.Type,
+			&ban.Reason,
+			&ban.Expiry,
+			&ban.CreatedAt,
+			&ban.User.ID,
+			&ban.User.Name,
+			&ban.User.Username,
+			&ban.User.AvatarID,
+		); err != nil {
+			return bans, err
+		}
+
+		bans = append(bans, ban)
+	}
+
+	if err = rows.Err(); err != nil {
+		return bans, err
+	}
+
+	return bans, nil
+}
+
+func (s *BanStore) Delete(ctx context.Context, id, communityID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM community_bans WHERE id = $1 AND community_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, id, communityID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func isBanned(ctx context.Context, tx *sql.Tx, communityID, userID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM community_bans
+			WHERE community_id = $1 AND user_id = $2 AND type = 'ban' AND (expiry IS NULL OR expiry > $3)
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var banned bool
+	if err := tx.QueryRowContext(ctx, query, communityID, userID, time.Now()).Scan(&banned); err != nil {
+		return false, err
+	}
+
+	return banned, nil
+}
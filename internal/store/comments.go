@@ -3,19 +3,25 @@ package store
 import (
 	"context"
 	"database/sql"
+
+	"github.com/skiba-mateusz/communiverse/internal/markdown"
 )
 
 type Comment struct {
-	ID        int64        `json:"id"`
-	Content   string       `json:"content"`
-	PostID    int64        `json:"postID"`
-	UserID    int64        `json:"authorID"`
-	ParentID  *int64       `json:"parentID"`
-	User      UserOverview `json:"author"`
-	CreatedAt string       `json:"createdAt"`
-	Votes     int          `json:"votes"`
-	UserVote  int          `json:"userVote"`
-	Replies   []Comment    `json:"replies"`
+	ID          int64          `json:"id"`
+	Content     string         `json:"content"`
+	ContentHTML string         `json:"contentHtml"`
+	PostID      int64          `json:"postID"`
+	UserID      int64          `json:"authorID"`
+	ParentID    *int64         `json:"parentID"`
+	User        UserOverview   `json:"author"`
+	AuthorFlair *FlairOverview `json:"authorFlair"`
+	EditedAt    *string        `json:"editedAt"`
+	Tombstone   string         `json:"tombstone,omitempty"`
+	CreatedAt   string         `json:"createdAt"`
+	Votes       int            `json:"votes"`
+	UserVote    int            `json:"userVote"`
+	Replies     []Comment      `json:"replies"`
 }
 
 type CommentStore struct {
@@ -24,17 +30,20 @@ type CommentStore struct {
 
 func (s *CommentStore) Create(ctx context.Context, comment *Comment) error {
 	query := `
-		INSERT INTO comments (content, user_id, post_id, parent_id) 
-		VALUES ($1, $2, $3, $4) RETURNING id, created_at
+		INSERT INTO comments (content, content_html, user_id, post_id, parent_id) 
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
+	comment.ContentHTML = markdown.Render(comment.Content)
+
 	err := s.db.QueryRowContext(
 		ctx,
 		query,
 		comment.Content,
+		comment.ContentHTML,
 		comment.UserID,
 		comment.PostID,
 		comment.ParentID,
@@ -49,37 +58,71 @@ func (s *CommentStore) Create(ctx context.Context, comment *Comment) error {
 	return nil
 }
 
-func (s *CommentStore) Update(ctx context.Context, comment *Comment) error {
+// Update saves the comment, edits to its content are kept as revisions along
+// with the original version.
+func (s *CommentStore) Update(ctx context.Context, comment *Comment, editorID int64, entry *ModLogEntry) error {
+	currentQuery := `SELECT content FROM comments WHERE id = $1 FOR UPDATE`
+	originalQuery := `
+		INSERT INTO comment_revisions (comment_id, editor_id, content, created_at)
+		SELECT id, user_id, content, created_at FROM comments
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM comment_revisions WHERE comment_id = $1)
+	`
+	revisionQuery := `
+		INSERT INTO comment_revisions (comment_id, editor_id, content)
+		VALUES ($1, $2, $3)
+	`
 	query := `
 		UPDATE comments
-		SET content = $1
-		WHERE id = $2
+		SET content = $1, content_html = $2, edited_at = NOW()
+		WHERE id = $3
+		RETURNING edited_at
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
 
-	res, err := s.db.ExecContext(ctx, query, comment.Content, comment.ID)
-	if err != nil {
-		return err
-	}
+		var content string
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return ErrNotFound
-	}
+		err := tx.QueryRowContext(queryCtx, currentQuery, comment.ID).Scan(&content)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+		if content == comment.Content {
+			return nil
+		}
 
-	return nil
+		if _, err = tx.ExecContext(queryCtx, originalQuery, comment.ID); err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(queryCtx, revisionQuery, comment.ID, editorID, comment.Content); err != nil {
+			return err
+		}
+
+		comment.ContentHTML = markdown.Render(comment.Content)
+
+		err = tx.QueryRowContext(queryCtx, query, comment.Content, comment.ContentHTML, comment.ID).Scan(&comment.EditedAt)
+		if err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
 }
 
+// Delete marks the comment as deleted by its author, it stays behind as a
+// tombstone so its replies remain in place.
 func (s *CommentStore) Delete(ctx context.Context, id int64) error {
 	query := `
-		DELETE FROM comments
-		WHERE id = $1
+		UPDATE comments SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -104,36 +147,48 @@ func (s *CommentStore) Delete(ctx context.Context, id int64) error {
 func (s *CommentStore) GetByID(ctx context.Context, commentID, userID int64) (*Comment, error) {
 	query := `
 		SELECT 
-			c.id, c.content, c.post_id, c.user_id, c.parent_id, c.created_at, 
+			c.id, c.content, c.content_html, c.post_id, c.user_id, c.parent_id, c.edited_at, c.created_at, 
 			u.id, u.name, u.username,
+			af.id, af.label, af.text_color, af.background_color,
 			COALESCE(SUM(cv.value), 0) AS num_votes,
 			COALESCE(uv.value, 0) AS user_vote
 		FROM comments c
 		INNER JOIN users u ON c.user_id = u.id
+		INNER JOIN posts p ON p.id = c.post_id
+		LEFT JOIN user_communities auc ON auc.community_id = p.community_id AND auc.user_id = c.user_id
+		LEFT JOIN community_flairs af ON af.id = auc.flair_id
 		LEFT JOIN comment_votes cv ON cv.comment_id = c.id
 		LEFT JOIN comment_votes uv ON uv.comment_id = c.id AND uv.user_id = $1
-		WHERE c.id = $2
+		WHERE c.id = $2 AND c.removed_at IS NULL AND c.deleted_at IS NULL
 		GROUP BY 
 			c.id, c.content, c.user_id, c.post_id, c.parent_id, c.created_at, 
 			u.id, u.name, u.username, u.bio, u.created_at,
-			uv.value
+			af.id, uv.value
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
 	comment := &Comment{}
+	var contentHTML sql.NullString
+	var authorFlair nullFlair
 
 	err := s.db.QueryRowContext(ctx, query, userID, commentID).Scan(
 		&comment.ID,
 		&comment.Content,
+		&contentHTML,
 		&comment.PostID,
 		&comment.UserID,
 		&comment.ParentID,
+		&comment.EditedAt,
 		&comment.CreatedAt,
 		&comment.User.ID,
 		&comment.User.Name,
 		&comment.User.Username,
+		&authorFlair.ID,
+		&authorFlair.Label,
+		&authorFlair.TextColor,
+		&authorFlair.BackgroundColor,
 		&comment.Votes,
 		&comment.UserVote,
 	)
@@ -146,25 +201,32 @@ func (s *CommentStore) GetByID(ctx context.Context, commentID, userID int64) (*C
 		}
 	}
 
+	comment.ContentHTML = renderedHTML(comment.Content, contentHTML)
+	comment.AuthorFlair = authorFlair.overview()
+
 	return comment, nil
 }
 
 func (s *CommentStore) GetByPostID(ctx context.Context, postID, userID int64) ([]Comment, error) {
 	query := `
 		SELECT 
-			c.id, c.content, c.user_id, c.post_id, c.parent_id, c.created_at, 
+			c.id, c.content, c.content_html, c.user_id, c.post_id, c.parent_id, c.edited_at, ` + tombstoneColumn("c") + `, c.created_at, 
 			u.id, u.name, u.username,
+			af.id, af.label, af.text_color, af.background_color,
 			COALESCE(SUM(cv.value), 0) AS num_votes,
 			COALESCE(uv.value, 0) AS user_vote
 		FROM comments c
 		INNER JOIN users u ON c.user_id = u.id
+		INNER JOIN posts p ON p.id = c.post_id
+		LEFT JOIN user_communities auc ON auc.community_id = p.community_id AND auc.user_id = c.user_id
+		LEFT JOIN community_flairs af ON af.id = auc.flair_id
 		LEFT JOIN comment_votes cv ON cv.comment_id = c.id
 		LEFT JOIN comment_votes uv ON uv.comment_id = c.id AND uv.user_id = $1
 		WHERE c.post_id = $2
 		GROUP BY 
 			c.id, c.content, c.user_id, c.post_id, c.created_at, 
 			u.id, u.name, u.username, u.bio, u.created_at,
-			uv.value
+			af.id, uv.value
 		ORDER BY c.created_at DESC
 	`
 
@@ -186,24 +248,37 @@ func (s *CommentStore) GetByPostID(ctx context.Context, postID, userID int64) ([
 
 	for rows.Next() {
 		var comment Comment
+		var contentHTML sql.NullString
+		var authorFlair nullFlair
 		comment.Replies = []Comment{}
 
 		if err := rows.Scan(
 			&comment.ID,
 			&comment.Content,
+			&contentHTML,
 			&comment.UserID,
 			&comment.PostID,
 			&comment.ParentID,
+			&comment.EditedAt,
+			&comment.Tombstone,
 			&comment.CreatedAt,
 			&comment.User.ID,
 			&comment.User.Name,
 			&comment.User.Username,
+			&authorFlair.ID,
+			&authorFlair.Label,
+			&authorFlair.TextColor,
+			&authorFlair.BackgroundColor,
 			&comment.Votes,
 			&comment.UserVote,
 		); err != nil {
 			return comments, err
 		}
 
+		comment.ContentHTML = renderedHTML(comment.Content, contentHTML)
+		comment.AuthorFlair = authorFlair.overview()
+		comment.mask()
+
 		comments = append(comments, comment)
 	}
 
@@ -20,6 +20,7 @@ type CommunityOverview struct {
 type CommunitySummary struct {
 	BaseCommunity
 	Description  string `json:"description"`
+	Visibility   string `json:"visibility"`
 	Role         Role   `json:"role"`
 	NumMembers   int    `json:"numMembers"`
 	CreatedAt    string `json:"createdAt"`
@@ -28,12 +29,15 @@ type CommunitySummary struct {
 type CommunityDetails struct {
 	BaseCommunity
 	Description  string      `json:"description"`
+	Visibility   string      `json:"visibility"`
+	PublicModLog bool        `json:"publicModLog"`
 	UserID       int64       `json:"creatorID"`
 	User         UserSummary `json:"creator"`
 	Role         Role        `json:"role"`
 	CreatedAt    string      `json:"createdAt"`
 	NumMembers   int         `json:"numMembers"`
 	NumPosts     int         `json:"numPosts"`
+	Rules        []Rule      `json:"rules,omitempty"`
 }
 
 type CommunityStore struct {
@@ -46,7 +50,7 @@ func (s *CommunityStore) Create(ctx context.Context, community *CommunityDetails
 			return err
 		}
 
-		if err := s.join(ctx, tx, community.ID, community.UserID, community.Role.Name); err != nil {
+		if err := joinCommunity(ctx, tx, community.ID, community.UserID, community.Role.Name); err != nil {
 			return err
 		}
 
@@ -57,7 +61,7 @@ func (s *CommunityStore) Create(ctx context.Context, community *CommunityDetails
 func (s *CommunityStore) GetBySlug(ctx context.Context, slug string, userID int64) (*CommunityDetails, error) {
 	query := `
 		SELECT 
-			c.id, c.name, c.description, c.slug, thumbnail_id, c.user_id, c.created_at,
+			c.id, c.name, c.description, c.slug, thumbnail_id, c.user_id, c.created_at, c.visibility, c.public_modlog,
 			u.id, u.name, u.username, u.bio, u.avatar_id, u.created_at,
 			COALESCE(r.id, -1),
 			COALESCE(r.name, 'Visitor'), 
@@ -75,7 +79,7 @@ func (s *CommunityStore) GetBySlug(ctx context.Context, slug string, userID int6
 		LEFT JOIN 
 			user_communities uc ON uc.community_id = c.id
 		LEFT JOIN 
-			posts p ON p.community_id = c.id
+			posts p ON p.community_id = c.id AND p.status = 'published' AND p.deleted_at IS NULL
 		WHERE 
 			c.slug = $2
 		GROUP BY 
@@ -102,6 +106,8 @@ func (s *CommunityStore) GetBySlug(ctx context.Context, slug string, userID int6
 		&community.ThumbnailID,
 		&community.UserID,
 		&community.CreatedAt,
+		&community.Visibility,
+		&community.PublicModLog,
 		&community.User.ID,
 		&community.User.Name,
 		&community.User.Username,
@@ -126,71 +132,83 @@ func (s *CommunityStore) GetBySlug(ctx context.Context, slug string, userID int6
 	return &community, nil
 }
 
-func (s *CommunityStore) Delete(ctx context.Context, id int64) error {
+// Delete deletes the community and records entry in the mod log, the entry is
+// written first so it can still reference the community.
+func (s *CommunityStore) Delete(ctx context.Context, id int64, entry *ModLogEntry) error {
 	query := `DELETE FROM communities WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := createModLogEntry(ctx, tx, entry); err != nil {
+			return err
+		}
 
-	res, err := s.db.ExecContext(
-		ctx,
-		query,
-		id,
-	)
-	if err != nil {
-		return err
-	}
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
+		res, err := tx.ExecContext(
+			ctx,
+			query,
+			id,
+		)
+		if err != nil {
+			return err
+		}
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	return nil
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
 }
 
-func (s *CommunityStore) Update(ctx context.Context, community *CommunityDetails) error {
+func (s *CommunityStore) Update(ctx context.Context, community *CommunityDetails, entry *ModLogEntry) error {
 	query := `
 		UPDATE communities
-		SET name = $1, description = $2, slug = $3, thumbnail_id = $4
-		WHERE id = $5
+		SET name = $1, description = $2, slug = $3, thumbnail_id = $4, visibility = $5, public_modlog = $6
+		WHERE id = $7
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
-
-	res, err := s.db.ExecContext(
-		ctx,
-		query,
-		community.Name,
-		community.Description,
-		community.Slug,
-		community.ThumbnailID,
-		community.ID,
-	)
-	if err != nil {
-		return err
-	}
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(
+			ctx,
+			query,
+			community.Name,
+			community.Description,
+			community.Slug,
+			community.ThumbnailID,
+			community.Visibility,
+			community.PublicModLog,
+			community.ID,
+		)
+		if err != nil {
+			return err
+		}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+		if rows == 0 {
+			return ErrNotFound
+		}
 
-	return nil
+		return createModLogEntry(ctx, tx, entry)
+	})
 }
 
 func (s *CommunityStore) Join(ctx context.Context, communityID, userID int64, roleName string) error {
 	return withTx(ctx, s.db, func(tx *sql.Tx) error {
-		if err := s.join(ctx, tx, communityID, userID, roleName); err != nil {
+		if err := joinCommunity(ctx, tx, communityID, userID, roleName); err != nil {
 			return err
 		}
 
@@ -229,7 +247,7 @@ func (s *CommunityStore) Leave(ctx context.Context, communityID, userID int64) e
 func (s *CommunityStore) GetAll(ctx context.Context, userID int64, q PaginatedCommunitiesQuery) ([]CommunitySummary, error) {
 	query := `
 		SELECT 
-			c.id, c.name, c.description, c.slug, c.thumbnail_id, c.created_at,
+			c.id, c.name, c.description, c.slug, c.thumbnail_id, c.created_at, c.visibility,
 			COALESCE(r.id, -1),
 			COALESCE(r.name, 'Visitor'), 
 			COALESCE(r.level, 0),
@@ -243,7 +261,7 @@ func (s *CommunityStore) GetAll(ctx context.Context, userID int64, q PaginatedCo
 		LEFT JOIN 
 			user_communities uc ON uc.community_id = c.id
 		LEFT JOIN 
-			posts p ON p.community_id = c.id
+			posts p ON p.community_id = c.id AND p.status = 'published' AND p.deleted_at IS NULL
 		WHERE 
 			c.name ILIKE '%' || $2 || '%' OR c.description ILIKE '%' || $2 || '%'
 		GROUP BY 
@@ -282,6 +300,7 @@ func (s *CommunityStore) GetAll(ctx context.Context, userID int64, q PaginatedCo
 			&community.Slug,
 			&community.ThumbnailID,
 			&community.CreatedAt,
+			&community.Visibility,
 			&community.Role.ID,
 			&community.Role.Name,
 			&community.Role.Level,
@@ -356,8 +375,8 @@ func (s *CommunityStore) GetUserCommunities(ctx context.Context, userID int64, q
 
 func (s *CommunityStore) create(ctx context.Context, tx *sql.Tx, community *CommunityDetails) error {
 	query := `
-	INSERT INTO communities (name, description, slug, thumbnail_id, user_id)
-	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	INSERT INTO communities (name, description, slug, thumbnail_id, user_id, visibility)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at
 `
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -370,6 +389,7 @@ func (s *CommunityStore) create(ctx context.Context, tx *sql.Tx, community *Comm
 		community.Slug,
 		community.ThumbnailID,
 		community.UserID,
+		community.Visibility,
 	).Scan(
 		&community.ID,
 		&community.CreatedAt,
@@ -381,7 +401,7 @@ func (s *CommunityStore) create(ctx context.Context, tx *sql.Tx, community *Comm
 	return nil
 }
 
-func (s *CommunityStore) join(ctx context.Context, tx *sql.Tx, communityID, userID int64, roleName string) error {
+func joinCommunity(ctx context.Context, tx *sql.Tx, communityID, userID int64, roleName string) error {
 	existsQuery := `
 		SELECT EXISTS (
 			SELECT 1 FROM user_communities WHERE user_id = $1 AND community_id = $2
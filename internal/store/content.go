@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/skiba-mateusz/communiverse/internal/markdown"
+)
+
+// renderedHTML returns the HTML rendered when the content was written, content
+// written before rendering was added is rendered on the fly until it's
+// backfilled.
+func renderedHTML(content string, html sql.NullString) string {
+	if html.Valid {
+		return html.String
+	}
+
+	return markdown.Render(content)
+}
+
+type ContentStore struct {
+	db *sql.DB
+}
+
+// RenderPending renders and stores the HTML of up to limit posts and up to
+// limit comments that don't have it yet.
+func (s *ContentStore) RenderPending(ctx context.Context, limit int) (int64, error) {
+	var rendered int64
+
+	for _, table := range []string{"posts", "comments"} {
+		n, err := s.renderPending(ctx, table, limit)
+		if err != nil {
+			return rendered, err
+		}
+
+		rendered += n
+	}
+
+	return rendered, nil
+}
+
+func (s *ContentStore) renderPending(ctx context.Context, table string, limit int) (int64, error) {
+	selectQuery := `SELECT id, content FROM ` + table + ` WHERE content_html IS NULL ORDER BY id LIMIT $1`
+	updateQuery := `UPDATE ` + table + ` SET content_html = $1 WHERE id = $2 AND content = $3 AND content_html IS NULL`
+
+	var rendered int64
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		rows, err := tx.QueryContext(ctx, selectQuery, limit)
+		if err != nil {
+			return err
+		}
+
+		type pending struct {
+			id      int64
+			content string
+		}
+
+		var batch []pending
+
+		for rows.Next() {
+			var p pending
+			if err = rows.Scan(&p.id, &p.content); err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, p)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		for _, p := range batch {
+			res, err := tx.ExecContext(ctx, updateQuery, markdown.Render(p.content), p.id, p.content)
+			if err != nil {
+				return err
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			rendered += rows
+		}
+
+		return nil
+	})
+
+	return rendered, err
+}
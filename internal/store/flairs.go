@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	FlairTypePost = "post"
+	FlairTypeUser = "user"
+)
+
+var ErrDuplicateFlair = fmt.Errorf("a flair with that label already exists")
+
+type FlairOverview struct {
+	ID              int64  `json:"id"`
+	Label           string `json:"label"`
+	TextColor       string `json:"textColor"`
+	BackgroundColor string `json:"backgroundColor"`
+}
+
+type Flair struct {
+	FlairOverview
+	CommunityID int64  `json:"communityID"`
+	Type        string `json:"type"`
+	AdminOnly   bool   `json:"adminOnly"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// nullFlair scans an optional, left joined flair.
+type nullFlair struct {
+	ID              sql.NullInt64
+	Label           sql.NullString
+	TextColor       sql.NullString
+	BackgroundColor sql.NullString
+}
+
+func (f nullFlair) overview() *FlairOverview {
+	if !f.ID.Valid {
+		return nil
+	}
+
+	return &FlairOverview{
+		ID:              f.ID.Int64,
+		Label:           f.Label.String,
+		TextColor:       f.TextColor.String,
+		BackgroundColor: f.BackgroundColor.String,
+	}
+}
+
+type FlairStore struct {
+	db *sql.DB
+}
+
+func (s *FlairStore) GetCommunityFlairs(ctx context.Context, communityID int64, flairType string) ([]Flair, error) {
+	query := `
+		SELECT id, community_id, type, label, text_color, background_color, admin_only, created_at
+		FROM community_flairs
+		WHERE community_id = $1 AND ($2 = '' OR type = $2)
+		ORDER BY type, label
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	flairs := []Flair{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID, flairType)
+	if err != nil {
+		return flairs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var flair Flair
+
+		if err := rows.Scan(
+			&flair.ID,
+			&flair.CommunityID,
+			&flair.Type,
+			&flair.Label,
+			&flair.TextColor,
+			&flair.BackgroundColor,
+			&flair.AdminOnly,
+			&flair.CreatedAt,
+		); err != nil {
+			return flairs, err
+		}
+
+		flairs = append(flairs, flair)
+	}
+
+	if err = rows.Err(); err != nil {
+		return flairs, err
+	}
+
+	return flairs, nil
+}
+
+func (s *FlairStore) GetByID(ctx context.Context, id, communityID int64) (*Flair, error) {
+	query := `
+		SELECT id, community_id, type, label, text_color, background_color, admin_only, created_at
+		FROM community_flairs
+		WHERE id = $1 AND community_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	flair := &Flair{}
+
+	err := s.db.QueryRowContext(ctx, query, id, communityID).Scan(
+		&flair.ID,
+		&flair.CommunityID,
+		&flair.Type,
+		&flair.Label,
+		&flair.TextColor,
+		&flair.BackgroundColor,
+		&flair.AdminOnly,
+		&flair.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return flair, nil
+}
+
+func (s *FlairStore) Create(ctx context.Context, flair *Flair, entry *ModLogEntry) error {
+	query := `
+		INSERT INTO community_flairs (community_id, type, label, text_color, background_color, admin_only)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			flair.CommunityID,
+			flair.Type,
+			flair.Label,
+			flair.TextColor,
+			flair.BackgroundColor,
+			flair.AdminOnly,
+		).Scan(
+			&flair.ID,
+			&flair.CreatedAt,
+		)
+		if err != nil {
+			return flairError(err)
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *FlairStore) Update(ctx context.Context, flair *Flair, entry *ModLogEntry) error {
+	query := `
+		UPDATE community_flairs SET label = $1, text_color = $2, background_color = $3, admin_only = $4
+		WHERE id = $5 AND community_id = $6
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(
+			ctx,
+			query,
+			flair.Label,
+			flair.TextColor,
+			flair.BackgroundColor,
+			flair.AdminOnly,
+			flair.ID,
+			flair.CommunityID,
+		)
+		if err != nil {
+			return flairError(err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *FlairStore) Delete(ctx context.Context, id, communityID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM community_flairs WHERE id = $1 AND community_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, id, communityID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// SetUserFlair sets or, given a nil flair, clears the flair a member shows in
+// the community.
+func (s *FlairStore) SetUserFlair(ctx context.Context, communityID, userID int64, flairID *int64, entry *ModLogEntry) error {
+	query := `UPDATE user_communities SET flair_id = $1 WHERE community_id = $2 AND user_id = $3`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, flairID, communityID, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func flairError(err error) error {
+	switch {
+	case err.Error() == `pq: duplicate key value violates unique constraint "community_flairs_community_id_type_label_key"`:
+		return ErrDuplicateFlair
+	default:
+		return err
+	}
+}
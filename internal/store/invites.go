@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+type Invite struct {
+	ID          int64   `json:"id"`
+	CommunityID int64   `json:"communityID"`
+	UserID      int64   `json:"creatorID"`
+	MaxUses     *int    `json:"maxUses"`
+	Uses        int     `json:"uses"`
+	Expiry      *string `json:"expiry"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+type InviteStore struct {
+	db *sql.DB
+}
+
+func (s *InviteStore) Create(ctx context.Context, invite *Invite, hashToken string, exp *time.Duration, entry *ModLogEntry) error {
+	query := `
+		INSERT INTO community_invites (community_id, user_id, token, max_uses, expiry)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, expiry, created_at
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var expiry *time.Time
+		if exp != nil {
+			t := time.Now().Add(*exp)
+			expiry = &t
+		}
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			invite.CommunityID,
+			invite.UserID,
+			hashToken,
+			invite.MaxUses,
+			expiry,
+		).Scan(
+			&invite.ID,
+			&invite.Expiry,
+			&invite.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *InviteStore) GetCommunityInvites(ctx context.Context, communityID int64) ([]Invite, error) {
+	query := `
+		SELECT id, community_id, COALESCE(user_id, 0), max_uses, uses, expiry, created_at
+		FROM community_invites
+		WHERE community_id = $1
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	invites := []Invite{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID)
+	if err != nil {
+		return invites, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var invite Invite
+
+		if err := rows.Scan(
+			&invite.ID,
+			&invite.CommunityID,
+			&invite.UserID,
+			&invite.MaxUses,
+			&invite.Uses,
+			&invite.Expiry,
+			&invite.CreatedAt,
+		); err != nil {
+			return invites, err
+		}
+
+		invites = append(invites, invite)
+	}
+
+	if err = rows.Err(); err != nil {
+		return invites, err
+	}
+
+	return invites, nil
+}
+
+// Accept uses up one invite and joins the user to the community, it fails
+// with ErrNotFound when the invite is unknown, expired or exhausted.
+func (s *InviteStore) Accept(ctx context.Context, plainToken string, communityID, userID int64) error {
+	query := `
+		UPDATE community_invites SET uses = uses + 1
+		WHERE token = $1 AND community_id = $2
+			AND (expiry IS NULL OR expiry > $3)
+			AND (max_uses IS NULL OR uses < max_uses)
+	`
+	deleteRequestQuery := `DELETE FROM community_join_requests WHERE community_id = $1 AND user_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		hash := sha256.Sum256([]byte(plainToken))
+		hashToken := hex.EncodeToString(hash[:])
+
+		res, err := tx.ExecContext(queryCtx, query, hashToken, communityID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		if _, err = tx.ExecContext(queryCtx, deleteRequestQuery, communityID, userID); err != nil {
+			return err
+		}
+
+		return joinCommunity(ctx, tx, communityID, userID, "member")
+	})
+}
+
+func (s *InviteStore) Delete(ctx context.Context, id, communityID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM community_invites WHERE id = $1 AND community_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, id, communityID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
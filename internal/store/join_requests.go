@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type JoinRequest struct {
+	ID          int64        `json:"id"`
+	CommunityID int64        `json:"communityID"`
+	User        UserOverview `json:"user"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+type JoinRequestStore struct {
+	db *sql.DB
+}
+
+func (s *JoinRequestStore) Create(ctx context.Context, request *JoinRequest) error {
+	query := `
+		INSERT INTO community_join_requests (community_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (community_id, user_id) DO UPDATE SET community_id = EXCLUDED.community_id
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, request.CommunityID, request.User.ID).Scan(
+		&request.ID,
+		&request.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *JoinRequestStore) GetCommunityRequests(ctx context.Context, communityID int64) ([]JoinRequest, error) {
+	query := `
+		SELECT jr.id, jr.community_id, jr.created_at, u.id, u.name, u.username, u.avatar_id
+		FROM community_join_requests jr
+		INNER JOIN users u ON u.id = jr.user_id
+		WHERE jr.community_id = $1
+		ORDER BY jr.created_at ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	requests := []JoinRequest{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID)
+	if err != nil {
+		return requests, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var request JoinRequest
+
+		if err := rows.Scan(
+			&request.ID,
+			&request.CommunityID,
+			&request.CreatedAt,
+			&request.User.ID,
+			&request.User.Name,
+			&request.User.Username,
+			&request.User.AvatarID,
+		); err != nil {
+			return requests, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return requests, err
+	}
+
+	return requests, nil
+}
+
+func (s *JoinRequestStore) Approve(ctx context.Context, id, communityID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM community_join_requests WHERE id = $1 AND community_id = $2 RETURNING user_id`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var userID int64
+		if err := tx.QueryRowContext(queryCtx, query, id, communityID).Scan(&userID); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		// The user may have been banned since they asked to join.
+		banned, err := isBanned(ctx, tx, communityID, userID)
+		if err != nil {
+			return err
+		}
+
+		if banned {
+			return ErrBanned
+		}
+
+		if err := joinCommunity(ctx, tx, communityID, userID, "member"); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *JoinRequestStore) Delete(ctx context.Context, id, communityID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM community_join_requests WHERE id = $1 AND community_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, id, communityID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
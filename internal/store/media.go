@@ -0,0 +1,310 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMediaLimit         = fmt.Errorf("the maximum number of images on a post has been reached")
+	ErrMediaOrderMismatch = fmt.Errorf("the order must list every image of the post exactly once")
+)
+
+type PostMedia struct {
+	ID           int64  `json:"id"`
+	PostID       int64  `json:"postID"`
+	FileID       string `json:"fileID"`
+	Position     int    `json:"position"`
+	Caption      string `json:"caption"`
+	AltText      string `json:"altText"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	URL          string `json:"url"`
+	PreviewURL   string `json:"previewURL"`
+	ThumbnailURL string `json:"thumbnailURL"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+type MediaStore struct {
+	db *sql.DB
+}
+
+func (s *MediaStore) GetPostMedia(ctx context.Context, postID int64) ([]PostMedia, error) {
+	query := `
+		SELECT id, post_id, file_id, position, caption, alt_text, width, height, created_at
+		FROM post_media
+		WHERE post_id = $1
+		ORDER BY position, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	media := []PostMedia{}
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return media, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m PostMedia
+
+		if err = rows.Scan(
+			&m.ID,
+			&m.PostID,
+			&m.FileID,
+			&m.Position,
+			&m.Caption,
+			&m.AltText,
+			&m.Width,
+			&m.Height,
+			&m.CreatedAt,
+		); err != nil {
+			return media, err
+		}
+
+		media = append(media, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return media, err
+	}
+
+	return media, nil
+}
+
+func (s *MediaStore) GetByID(ctx context.Context, id, postID int64) (*PostMedia, error) {
+	query := `
+		SELECT id, post_id, file_id, position, caption, alt_text, width, height, created_at
+		FROM post_media
+		WHERE id = $1 AND post_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	m := &PostMedia{}
+
+	err := s.db.QueryRowContext(ctx, query, id, postID).Scan(
+		&m.ID,
+		&m.PostID,
+		&m.FileID,
+		&m.Position,
+		&m.Caption,
+		&m.AltText,
+		&m.Width,
+		&m.Height,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Create appends the media to the end of the post gallery, all or none of it
+// is added and the gallery can't grow past limit.
+func (s *MediaStore) Create(ctx context.Context, postID int64, media []PostMedia, limit int) error {
+	lockQuery := `SELECT id FROM posts WHERE id = $1 FOR UPDATE`
+	countQuery := `SELECT COUNT(*), COALESCE(MAX(position), 0) FROM post_media WHERE post_id = $1`
+	query := `
+		INSERT INTO post_media (post_id, file_id, position, caption, alt_text, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var id int64
+		if err := tx.QueryRowContext(queryCtx, lockQuery, postID).Scan(&id); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		var count, position int
+		if err := tx.QueryRowContext(queryCtx, countQuery, postID).Scan(&count, &position); err != nil {
+			return err
+		}
+
+		if count+len(media) > limit {
+			return ErrMediaLimit
+		}
+
+		for i := range media {
+			position++
+
+			media[i].PostID = postID
+			media[i].Position = position
+
+			err := tx.QueryRowContext(
+				queryCtx,
+				query,
+				postID,
+				media[i].FileID,
+				media[i].Position,
+				media[i].Caption,
+				media[i].AltText,
+				media[i].Width,
+				media[i].Height,
+			).Scan(
+				&media[i].ID,
+				&media[i].CreatedAt,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *MediaStore) Update(ctx context.Context, m *PostMedia) error {
+	query := `UPDATE post_media SET caption = $1, alt_text = $2 WHERE id = $3 AND post_id = $4`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, m.Caption, m.AltText, m.ID, m.PostID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Reorder sets the gallery order to the given ids, which must be exactly the
+// media of the post.
+func (s *MediaStore) Reorder(ctx context.Context, postID int64, ids []int64) error {
+	currentQuery := `SELECT id FROM post_media WHERE post_id = $1 ORDER BY id FOR UPDATE`
+	query := `UPDATE post_media SET position = array_position($1::bigint[], id) WHERE post_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		rows, err := tx.QueryContext(queryCtx, currentQuery, postID)
+		if err != nil {
+			return err
+		}
+
+		current := []int64{}
+		for rows.Next() {
+			var id int64
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			current = append(current, id)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		if !validMediaOrder(current, ids) {
+			return ErrMediaOrderMismatch
+		}
+
+		_, err = tx.ExecContext(queryCtx, query, pq.Array(ids), postID)
+		return err
+	})
+}
+
+// validMediaOrder reports whether ids lists every id in current, which is
+// sorted, exactly once.
+func validMediaOrder(current, ids []int64) bool {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+
+	return slices.Equal(current, sorted)
+}
+
+// Delete removes the media from the gallery and returns its file id so it can
+// be deleted from storage.
+func (s *MediaStore) Delete(ctx context.Context, id, postID int64) (string, error) {
+	query := `DELETE FROM post_media WHERE id = $1 AND post_id = $2 RETURNING file_id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var fileID string
+
+	err := s.db.QueryRowContext(ctx, query, id, postID).Scan(&fileID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return "", ErrNotFound
+		default:
+			return "", err
+		}
+	}
+
+	return fileID, nil
+}
+
+// deleteExpiredMedia removes the galleries of posts deleted or removed before
+// cutoff and returns their file ids to delete from storage. Galleries are kept
+// until then so restored posts come back with their images.
+func deleteExpiredMedia(ctx context.Context, tx *sql.Tx, cutoff time.Time) ([]string, error) {
+	query := `
+		DELETE FROM post_media
+		WHERE post_id IN (SELECT id FROM posts WHERE COALESCE(deleted_at, removed_at) < $1)
+		RETURNING file_id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	fileIDs := []string{}
+
+	rows, err := tx.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return fileIDs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fileID string
+
+		if err = rows.Scan(&fileID); err != nil {
+			return fileIDs, err
+		}
+
+		fileIDs = append(fileIDs, fileID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fileIDs, err
+	}
+
+	return fileIDs, nil
+}
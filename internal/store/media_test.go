@@ -0,0 +1,28 @@
+package store
+
+import "testing"
+
+func TestValidMediaOrder(t *testing.T) {
+	current := []int64{1, 2, 3}
+
+	tests := []struct {
+		name string
+		ids  []int64
+		ok   bool
+	}{
+		{"should accept a new order", []int64{3, 1, 2}, true},
+		{"should accept the current order", []int64{1, 2, 3}, true},
+		{"should reject a missing image", []int64{3, 1}, false},
+		{"should reject an image of another post", []int64{3, 1, 2, 4}, false},
+		{"should reject a repeated image", []int64{3, 1, 1}, false},
+		{"should reject an empty order", []int64{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok := validMediaOrder(current, tt.ids); ok != tt.ok {
+				t.Errorf("expected %v for %v, got %v", tt.ok, tt.ids, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Member struct {
+	User     UserOverview `json:"user"`
+	Role     Role         `json:"role"`
+	JoinedAt string       `json:"joinedAt"`
+}
+
+type MemberStore struct {
+	db *sql.DB
+}
+
+func (s *MemberStore) GetCommunityMembers(ctx context.Context, communityID int64, q PaginatedMembersQuery) ([]Member, error) {
+	query := `
+		SELECT
+			u.id, u.name, u.username, u.avatar_id,
+			r.id, r.name, r.level,
+			uc.created_at
+		FROM
+			user_communities uc
+		INNER JOIN
+			users u ON u.id = uc.user_id
+		INNER JOIN
+			roles r ON r.id = uc.role_id
+		WHERE
+			uc.community_id = $1
+			AND (u.username ILIKE '%' || $2 || '%' OR u.name ILIKE '%' || $2 || '%')
+			AND ($3 = '' OR r.name = $3)
+		ORDER BY
+			r.level DESC, uc.created_at ASC
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	members := []Member{}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		communityID,
+		q.Search,
+		q.Role,
+		q.Limit,
+		q.Offset,
+	)
+	if err != nil {
+		return members, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member Member
+
+		if err := rows.Scan(
+			&member.User.ID,
+			&member.User.Name,
+			&member.User.Username,
+			&member.User.AvatarID,
+			&member.Role.ID,
+			&member.Role.Name,
+			&member.Role.Level,
+			&member.JoinedAt,
+		); err != nil {
+			return members, err
+		}
+
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return members, err
+	}
+
+	return members, nil
+}
+
+func (s *MemberStore) GetByUsername(ctx context.Context, communityID int64, username string) (*Member, error) {
+	query := `
+		SELECT
+			u.id, u.name, u.username, u.avatar_id,
+			r.id, r.name, r.level,
+			uc.created_at
+		FROM
+			user_communities uc
+		INNER JOIN
+			users u ON u.id = uc.user_id
+		INNER JOIN
+			roles r ON r.id = uc.role_id
+		WHERE
+			uc.community_id = $1 AND u.username = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	member := &Member{}
+	err := s.db.QueryRowContext(ctx, query, communityID, username).Scan(
+		&member.User.ID,
+		&member.User.Name,
+		&member.User.Username,
+		&member.User.AvatarID,
+		&member.Role.ID,
+		&member.Role.Name,
+		&member.Role.Level,
+		&member.JoinedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return member, nil
+}
+
+func (s *MemberStore) UpdateRole(ctx context.Context, communityID, userID int64, roleName string, entry *ModLogEntry) error {
+	query := `
+		UPDATE user_communities
+		SET role_id = (SELECT id FROM roles WHERE name = $1)
+		WHERE community_id = $2 AND user_id = $3
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, roleName, communityID, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *MemberStore) Remove(ctx context.Context, communityID, userID int64, entry *ModLogEntry) error {
+	query := `DELETE FROM user_communities WHERE community_id = $1 AND user_id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, communityID, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
@@ -8,7 +8,8 @@ import (
 
 func NewMockStore() Storage {
 	return Storage{
-		Users: &MockUserStore{},
+		Users:    &MockUserStore{},
+		Sessions: &MockSessionStore{},
 	}
 }
 
@@ -50,6 +51,14 @@ func (m *MockUserStore) Activate(ctx context.Context, token string) error {
 	return nil
 }
 
+func (m *MockUserStore) ReplaceInvitation(ctx context.Context, token string, exp time.Duration, id int64) error {
+	return nil
+}
+
+func (m *MockUserStore) DeleteInactive(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
 func (m *MockUserStore) CreatePasswordReset(ctx context.Context, email string, exp time.Duration, id int64) error {
 	return nil
 }
@@ -57,3 +66,90 @@ func (m *MockUserStore) CreatePasswordReset(ctx context.Context, email string, e
 func (m *MockUserStore) ResetPassword(ctx context.Context, token string, password []byte) error {
 	return nil
 }
+
+func (m *MockUserStore) CreateMagicLink(ctx context.Context, token string, exp time.Duration, id int64) error {
+	return nil
+}
+
+func (m *MockUserStore) ConsumeMagicLink(ctx context.Context, token string) (int64, error) {
+	return 0, ErrNotFound
+}
+
+func (m *MockUserStore) ChangePassword(ctx context.Context, userID, sessionID int64, password []byte) error {
+	return nil
+}
+
+func (m *MockUserStore) CreateEmailChange(ctx context.Context, token string, exp time.Duration, userID, sessionID int64, email string) error {
+	return nil
+}
+
+func (m *MockUserStore) ConfirmEmailChange(ctx context.Context, token string) error {
+	return nil
+}
+
+func (m *MockUserStore) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	return nil
+}
+
+func (m *MockUserStore) EnableTOTP(ctx context.Context, userID int64, recoveryCodes []string) error {
+	return nil
+}
+
+func (m *MockUserStore) DisableTOTP(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (m *MockUserStore) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	return ErrNotFound
+}
+
+func (m *MockUserStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	return nil
+}
+
+func (m *MockUserStore) GetByIdentity(ctx context.Context, provider, subject string) (*UserDetails, error) {
+	return nil, ErrNotFound
+}
+
+func (m *MockUserStore) LinkIdentity(ctx context.Context, userID int64, provider, subject string, password []byte) error {
+	return nil
+}
+
+func (m *MockUserStore) CreateWithIdentity(ctx context.Context, ud *UserDetails, provider, subject string) error {
+	return nil
+}
+
+type MockSessionStore struct {
+}
+
+func (m *MockSessionStore) Create(ctx context.Context, session *Session, token string, exp time.Duration) error {
+	return nil
+}
+
+func (m *MockSessionStore) GetByID(ctx context.Context, id int64) (*Session, error) {
+	return &Session{ID: id, UserID: 42}, nil
+}
+
+func (m *MockSessionStore) Rotate(ctx context.Context, token, newToken string, exp time.Duration) (*Session, error) {
+	return nil, ErrNotFound
+}
+
+func (m *MockSessionStore) Revoke(ctx context.Context, id, userID int64) error {
+	return nil
+}
+
+func (m *MockSessionStore) RevokeAll(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (m *MockSessionStore) RevokeOthers(ctx context.Context, userID, sessionID int64) error {
+	return nil
+}
+
+func (m *MockSessionStore) GetUserSessions(ctx context.Context, userID int64) ([]Session, error) {
+	return []Session{}, nil
+}
+
+func (m *MockSessionStore) Touch(ctx context.Context, id int64, ip string) error {
+	return nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	ModActionRemovePost       = "remove_post"
+	ModActionRemoveComment    = "remove_comment"
+	ModActionApprovePost      = "approve_post"
+	ModActionApproveComment   = "approve_comment"
+	ModActionDeleteCommunity  = "delete_community"
+	ModActionBanUser          = "ban_user"
+	ModActionMuteUser         = "mute_user"
+	ModActionLiftBan          = "lift_ban"
+	ModActionUpdateMemberRole = "update_member_role"
+	ModActionRemoveMember     = "remove_member"
+	ModActionUpdateRules      = "update_rules"
+	ModActionForceTransfer    = "force_transfer"
+	ModActionUpdateAutoMod    = "update_automod"
+	ModActionLockPost         = "lock_post"
+	ModActionUnlockPost       = "unlock_post"
+	ModActionPinPost          = "pin_post"
+	ModActionUnpinPost        = "unpin_post"
+	ModActionRestorePost      = "restore_post"
+	ModActionRestoreComment   = "restore_comment"
+	ModActionUpdateSettings   = "update_settings"
+	ModActionApproveJoin      = "approve_join_request"
+	ModActionDenyJoin         = "deny_join_request"
+	ModActionCreateInvite     = "create_invite"
+	ModActionDeleteInvite     = "delete_invite"
+	ModActionCreateFlair      = "create_flair"
+	ModActionUpdateFlair      = "update_flair"
+	ModActionDeleteFlair      = "delete_flair"
+	ModActionSetMemberFlair   = "set_member_flair"
+	ModActionEditPost         = "edit_post"
+	ModActionEditComment      = "edit_comment"
+)
+
+type ModLogEntry struct {
+	ID          int64        `json:"id"`
+	CommunityID *int64       `json:"communityID"`
+	ActorID     *int64       `json:"actorID"`
+	Actor       UserOverview `json:"actor"`
+	Action      string       `json:"action"`
+	TargetType  string       `json:"targetType"`
+	TargetID    *int64       `json:"targetID"`
+	TargetLabel string       `json:"targetLabel"`
+	Reason      string       `json:"reason"`
+	Automated   bool         `json:"automated"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+type ModLogStore struct {
+	db *sql.DB
+}
+
+// createModLogEntry appends the entry to the mod log in the transaction of
+// the action it describes, so an action never happens without its entry.
+// Entries are never updated or deleted through the store. A nil entry is an
+// action that isn't logged, such as an author deleting their own post.
+func createModLogEntry(ctx context.Context, tx *sql.Tx, entry *ModLogEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO mod_log (community_id, actor_id, action, target_type, target_id, target_label, reason, automated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		entry.CommunityID,
+		entry.ActorID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.TargetLabel,
+		entry.Reason,
+		entry.Automated,
+	).Scan(
+		&entry.ID,
+		&entry.CreatedAt,
+	)
+}
+
+func (s *ModLogStore) GetCommunityLog(ctx context.Context, communityID int64, q PaginatedModLogQuery) ([]ModLogEntry, Meta, error) {
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(`
+		SELECT
+			l.id, l.community_id, l.actor_id, l.action, l.target_type, l.target_id, l.target_label, l.reason, l.automated, l.created_at,
+			COALESCE(u.id, 0), COALESCE(u.name, ''), COALESCE(u.username, '[deleted]'), COALESCE(u.avatar_id, ''),
+			COUNT(*) OVER() AS total
+		FROM mod_log l
+		LEFT JOIN users u ON u.id = l.actor_id
+		WHERE l.community_id = $1
+	`)
+
+	args := []any{communityID}
+
+	if q.Action != "" {
+		args = append(args, q.Action)
+		queryBuilder.WriteString(`
+			AND l.action = $` + fmt.Sprint(len(args)))
+	}
+
+	queryBuilder.WriteString(`
+		ORDER BY l.created_at DESC, l.id DESC
+	`)
+
+	queryBuilder.WriteString(`LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2))
+	args = append(args, q.Limit, q.Offset)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	entries := []ModLogEntry{}
+	var totalCount int
+
+	rows, err := s.db.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return entries, Meta{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry ModLogEntry
+
+		if err = rows.Scan(
+			&entry.ID,
+			&entry.CommunityID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&entry.TargetLabel,
+			&entry.Reason,
+			&entry.Automated,
+			&entry.CreatedAt,
+			&entry.Actor.ID,
+			&entry.Actor.Name,
+			&entry.Actor.Username,
+			&entry.Actor.AvatarID,
+			&totalCount,
+		); err != nil {
+			return entries, Meta{}, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return entries, Meta{}, err
+	}
+
+	meta := Meta{
+		TotalCount:  totalCount,
+		TotalPages:  (totalCount + q.Limit - 1) / q.Limit,
+		CurrentPage: q.Offset/q.Limit + 1,
+		Offset:      q.Offset,
+		Limit:       q.Limit,
+	}
+
+	return entries, meta, nil
+}
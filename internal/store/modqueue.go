@@ -0,0 +1,297 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ResolutionApproved = "approved"
+	ResolutionRemoved  = "removed"
+)
+
+var removableTables = map[string]string{
+	"post":    "posts",
+	"comment": "comments",
+}
+
+// queueTargetJoins resolves a (target_type, target_id) pair aliased as t into
+// the reported post or comment, its parent post, community and author.
+const queueTargetJoins = `
+	LEFT JOIN comments cm ON t.target_type = 'comment' AND cm.id = t.target_id
+	INNER JOIN posts p ON p.id = CASE WHEN t.target_type = 'post' THEN t.target_id ELSE cm.post_id END
+	INNER JOIN communities c ON c.id = p.community_id
+	INNER JOIN users u ON u.id = COALESCE(cm.user_id, p.user_id)
+`
+
+type QueueItem struct {
+	TargetType     string            `json:"targetType"`
+	TargetID       int64             `json:"targetID"`
+	Community      CommunityOverview `json:"community"`
+	User           UserOverview      `json:"author"`
+	PostTitle      string            `json:"postTitle"`
+	PostSlug       string            `json:"postSlug"`
+	Content        string            `json:"content"`
+	CreatedAt      string            `json:"createdAt"`
+	RemovedAt      *string           `json:"removedAt"`
+	NumReports     int               `json:"numReports"`
+	Reasons        []string          `json:"reasons"`
+	LastReportedAt string            `json:"lastReportedAt"`
+}
+
+type ModQueueStore struct {
+	db *sql.DB
+}
+
+// GetQueue lists reported content that is still visible and has unresolved
+// reports, most reported first. A nil communityID returns the site-wide queue.
+func (s *ModQueueStore) GetQueue(ctx context.Context, communityID *int64, q PaginatedQueueQuery) ([]QueueItem, Meta, error) {
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(`
+		SELECT
+			t.target_type, t.target_id,
+			c.id, c.name, c.slug, c.thumbnail_id,
+			u.id, u.name, u.username, u.avatar_id,
+			p.title, p.slug,
+			COALESCE(cm.content, p.content),
+			COALESCE(cm.created_at, p.created_at),
+			COUNT(t.id) AS num_reports,
+			array_agg(DISTINCT COALESCE(cr.title, t.reason)),
+			MAX(t.created_at) AS last_reported_at,
+			COUNT(*) OVER() AS total
+		FROM reports t
+	` + queueTargetJoins + `
+		LEFT JOIN community_rules cr ON cr.id = t.rule_id
+		WHERE t.resolved_at IS NULL AND p.removed_at IS NULL AND cm.removed_at IS NULL AND p.deleted_at IS NULL AND cm.deleted_at IS NULL
+	`)
+
+	args := []any{}
+
+	if communityID != nil {
+		args = append(args, *communityID)
+		queryBuilder.WriteString(`
+			AND t.community_id = $` + fmt.Sprint(len(args)))
+	}
+
+	if q.TargetType != "" {
+		args = append(args, q.TargetType)
+		queryBuilder.WriteString(`
+			AND t.target_type = $` + fmt.Sprint(len(args)))
+	}
+
+	queryBuilder.WriteString(`
+		GROUP BY
+			t.target_type, t.target_id,
+			c.id, c.name, c.slug, c.thumbnail_id,
+			u.id, u.name, u.username, u.avatar_id,
+			p.title, p.slug, p.content, p.created_at, cm.content, cm.created_at
+		ORDER BY num_reports DESC, last_reported_at DESC
+	`)
+
+	queryBuilder.WriteString(`LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2))
+	args = append(args, q.Limit, q.Offset)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	items := []QueueItem{}
+	var totalCount int
+
+	rows, err := s.db.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return items, Meta{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item QueueItem
+
+		if err = rows.Scan(
+			&item.TargetType,
+			&item.TargetID,
+			&item.Community.ID,
+			&item.Community.Name,
+			&item.Community.Slug,
+			&item.Community.ThumbnailID,
+			&item.User.ID,
+			&item.User.Name,
+			&item.User.Username,
+			&item.User.AvatarID,
+			&item.PostTitle,
+			&item.PostSlug,
+			&item.Content,
+			&item.CreatedAt,
+			&item.NumReports,
+			pq.Array(&item.Reasons),
+			&item.LastReportedAt,
+			&totalCount,
+		); err != nil {
+			return items, Meta{}, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return items, Meta{}, err
+	}
+
+	meta := Meta{
+		TotalCount:  totalCount,
+		TotalPages:  (totalCount + q.Limit - 1) / q.Limit,
+		CurrentPage: q.Offset/q.Limit + 1,
+		Offset:      q.Offset,
+		Limit:       q.Limit,
+	}
+
+	return items, meta, nil
+}
+
+// GetItem loads a post or comment for moderation, regardless of whether it
+// has been reported or already removed.
+func (s *ModQueueStore) GetItem(ctx context.Context, targetType string, targetID int64) (*QueueItem, error) {
+	query := `
+		SELECT
+			t.target_type, t.target_id,
+			c.id, c.name, c.slug, c.thumbnail_id,
+			u.id, u.name, u.username, u.avatar_id,
+			p.title, p.slug,
+			COALESCE(cm.content, p.content),
+			COALESCE(cm.created_at, p.created_at),
+			CASE WHEN t.target_type = 'post' THEN p.removed_at ELSE cm.removed_at END
+		FROM (SELECT $1::text AS target_type, $2::int AS target_id) t
+	` + queueTargetJoins
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	item := &QueueItem{Reasons: []string{}}
+
+	err := s.db.QueryRowContext(ctx, query, targetType, targetID).Scan(
+		&item.TargetType,
+		&item.TargetID,
+		&item.Community.ID,
+		&item.Community.Name,
+		&item.Community.Slug,
+		&item.Community.ThumbnailID,
+		&item.User.ID,
+		&item.User.Name,
+		&item.User.Username,
+		&item.User.AvatarID,
+		&item.PostTitle,
+		&item.PostSlug,
+		&item.Content,
+		&item.CreatedAt,
+		&item.RemovedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return item, nil
+}
+
+// Approve dismisses all open reports against the content and leaves it in
+// place, ErrNotFound is returned when there is nothing to approve.
+func (s *ModQueueStore) Approve(ctx context.Context, targetType string, targetID, moderatorID int64, entry *ModLogEntry) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		rows, err := resolveReports(queryCtx, tx, targetType, targetID, &moderatorID, ResolutionApproved)
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// Remove hides the content from listings without deleting it and resolves
+// any open reports against it.
+func (s *ModQueueStore) Remove(ctx context.Context, targetType string, targetID int64, moderatorID *int64, entry *ModLogEntry) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := removeContent(ctx, tx, targetType, targetID, moderatorID); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// RemoveAndBan removes the content and bans its author in one go, so neither
+// happens without the other or without their mod log entries.
+func (s *ModQueueStore) RemoveAndBan(ctx context.Context, targetType string, targetID int64, ban *Ban, exp *time.Duration, removeEntry, banEntry *ModLogEntry) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := removeContent(ctx, tx, targetType, targetID, ban.ModeratorID); err != nil {
+			return err
+		}
+
+		if err := createBan(ctx, tx, ban, exp); err != nil {
+			return err
+		}
+
+		if err := createModLogEntry(ctx, tx, removeEntry); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, banEntry)
+	})
+}
+
+func removeContent(ctx context.Context, tx *sql.Tx, targetType string, targetID int64, moderatorID *int64) error {
+	table, ok := removableTables[targetType]
+	if !ok {
+		return ErrNotFound
+	}
+
+	query := `UPDATE ` + table + ` SET removed_at = NOW(), removed_by = $1 WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, moderatorID, targetID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	_, err = resolveReports(ctx, tx, targetType, targetID, moderatorID, ResolutionRemoved)
+	return err
+}
+
+func resolveReports(ctx context.Context, tx *sql.Tx, targetType string, targetID int64, moderatorID *int64, resolution string) (int64, error) {
+	query := `
+		UPDATE reports SET resolved_at = NOW(), resolved_by = $1, resolution = $2
+		WHERE target_type = $3 AND target_id = $4 AND resolved_at IS NULL
+	`
+
+	res, err := tx.ExecContext(ctx, query, moderatorID, resolution, targetType, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
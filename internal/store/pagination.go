@@ -12,6 +12,7 @@ type PaginatedPostsQuery struct {
 	Time string `json:"time" validate:"oneof=today week month year all-time"`
 	View   string `json:"view" validate:"oneof=top discussed latest"`
 	Sort string `json:"sort" validate:"oneof=asc desc"`
+	Flair  string `json:"flair" validate:"max=50"`
 }
 
 type Meta struct {
@@ -63,6 +64,11 @@ func (pq PaginatedPostsQuery) Parse(r *http.Request) (PaginatedPostsQuery, error
 		pq.Sort = sort
 	}
 
+	flair := qs.Get("flair")
+	if flair != "" {
+		pq.Flair = flair
+	}
+
 	return pq, nil
 }
 
@@ -100,3 +106,143 @@ func (cq PaginatedCommunitiesQuery) Parse(r *http.Request) (PaginatedCommunities
 
 	return cq, nil
 }
+
+type PaginatedMembersQuery struct {
+	Search string `json:"search" validate:"max=100"`
+	Role   string `json:"role" validate:"omitempty,oneof=member moderator admin"`
+	Limit  int    `json:"limit" validate:"gte=1,lte=50"`
+	Offset int    `json:"offset" validate:"gte=0"`
+}
+
+func (mq PaginatedMembersQuery) Parse(r *http.Request) (PaginatedMembersQuery, error) {
+	qs := r.URL.Query()
+
+	search := qs.Get("search")
+	if search != "" {
+		mq.Search = search
+	}
+
+	role := qs.Get("role")
+	if role != "" {
+		mq.Role = role
+	}
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return mq, err
+		}
+		mq.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return mq, err
+		}
+		mq.Offset = o
+	}
+
+	return mq, nil
+}
+
+type PaginatedQueueQuery struct {
+	TargetType string `json:"type" validate:"omitempty,oneof=post comment"`
+	Limit      int    `json:"limit" validate:"gte=1,lte=50"`
+	Offset     int    `json:"offset" validate:"gte=0"`
+}
+
+func (qq PaginatedQueueQuery) Parse(r *http.Request) (PaginatedQueueQuery, error) {
+	qs := r.URL.Query()
+
+	targetType := qs.Get("type")
+	if targetType != "" {
+		qq.TargetType = targetType
+	}
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return qq, err
+		}
+		qq.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return qq, err
+		}
+		qq.Offset = o
+	}
+
+	return qq, nil
+}
+
+type PaginatedModLogQuery struct {
+	Action string `json:"action" validate:"max=50"`
+	Limit  int    `json:"limit" validate:"gte=1,lte=50"`
+	Offset int    `json:"offset" validate:"gte=0"`
+}
+
+func (lq PaginatedModLogQuery) Parse(r *http.Request) (PaginatedModLogQuery, error) {
+	qs := r.URL.Query()
+
+	action := qs.Get("action")
+	if action != "" {
+		lq.Action = action
+	}
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return lq, err
+		}
+		lq.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return lq, err
+		}
+		lq.Offset = o
+	}
+
+	return lq, nil
+}
+
+type PaginatedRevisionsQuery struct {
+	Limit  int `json:"limit" validate:"gte=1,lte=50"`
+	Offset int `json:"offset" validate:"gte=0"`
+}
+
+func (rq PaginatedRevisionsQuery) Parse(r *http.Request) (PaginatedRevisionsQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return rq, err
+		}
+		rq.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return rq, err
+		}
+		rq.Offset = o
+	}
+
+	return rq, nil
+}
@@ -4,15 +4,34 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/lib/pq"
+	"github.com/skiba-mateusz/communiverse/internal/markdown"
+)
+
+var ErrPinLimit = fmt.Errorf("the maximum number of pinned posts has been reached")
+
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+)
+
+type postScope int
+
+const (
+	scopePublished postScope = iota
+	scopeFeed
+	scopeDrafts
 )
 
 type BasePost struct {
 	ID          int64            `json:"id"`
 	Title       string           `json:"title"`
 	Content     string           `json:"content"`
+	ContentHTML string           `json:"contentHtml"`
 	Slug        string           `json:"slug"`
 	Tags        []string         `json:"tags"`
 	CommunityID int64            `json:"communityID"`
@@ -20,9 +39,25 @@ type BasePost struct {
 	NumComments int              `json:"numComments"`
 	Votes       int              `json:"votes"`
 	UserVote    int              `json:"userVote"`
+	Pinned      bool             `json:"pinned"`
+	Locked      bool             `json:"locked"`
+	Flair       *FlairOverview   `json:"flair"`
+	AuthorFlair *FlairOverview   `json:"authorFlair"`
+	Status      string           `json:"status"`
+	PublishAt   *string          `json:"publishAt"`
+	EditedAt    *string          `json:"editedAt"`
+	Tombstone   string           `json:"tombstone,omitempty"`
 	CreatedAt   string           `json:"createdAt"`
 }
 
+func (p *BasePost) flairID() *int64 {
+	if p.Flair == nil {
+		return nil
+	}
+
+	return &p.Flair.ID
+}
+
 type PostSummary struct {
 	BasePost
 	Community   CommunityOverview `json:"community"`
@@ -33,6 +68,7 @@ type PostDetails struct {
 	BasePost
 	Community   CommunitySummary `json:"community"`
 	User        UserSummary      `json:"author"`
+	Media       []PostMedia      `json:"media,omitempty"`
 }
 
 type PostStore struct {
@@ -41,22 +77,28 @@ type PostStore struct {
 
 func (s *PostStore) Create(ctx context.Context, post *PostDetails) error {
 	query := `
-		INSERT INTO posts (title, content, slug, tags, community_id, user_id)
-		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at
+		INSERT INTO posts (title, content, content_html, slug, tags, community_id, user_id, flair_id, status, publish_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
+	post.ContentHTML = markdown.Render(post.Content)
+
 	err := s.db.QueryRowContext(
 		ctx,
 		query,
 		post.Title,
 		post.Content,
+		post.ContentHTML,
 		post.Slug,
 		pq.Array(post.Tags),
 		post.CommunityID,
 		post.UserID,
+		post.flairID(),
+		post.Status,
+		post.PublishAt,
 	).Scan(
 		&post.ID,
 		&post.CreatedAt,
@@ -71,13 +113,15 @@ func (s *PostStore) Create(ctx context.Context, post *PostDetails) error {
 func (s *PostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*PostDetails, error) {
 	query := `
 		SELECT 
-			p.id, p.title, p.content, p.tags, p.slug, p.user_id, p.community_id, p.created_at,
+			p.id, p.title, p.content, p.content_html, p.tags, p.slug, p.user_id, p.community_id, p.pinned_at IS NOT NULL, p.locked, p.status, p.publish_at, p.edited_at, ` + tombstoneColumn("p") + `, p.created_at,
 			c.id, c.name, c.description, c.slug, c.thumbnail_id, c.created_at,
 			COALESCE(r.id, -1),
 			COALESCE(r.name, 'Visitor'), 
 			COALESCE(r.level, 0),
 			COALESCE(tm.num_members, 0) AS num_members,
 			u.id, u.name, u.username, u.bio, u.avatar_id, u.created_at,
+			pf.id, pf.label, pf.text_color, pf.background_color,
+			af.id, af.label, af.text_color, af.background_color,
 			COALESCE(COUNT(cm.id), 0) AS num_comments,
 			COALESCE(tv.total_votes, 0) AS votes,
 			COALESCE(uv.user_vote, 0) AS user_vote
@@ -88,9 +132,15 @@ func (s *PostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*
 		INNER JOIN 
 		    users u ON u.id = p.user_id
 		LEFT JOIN 
-		    comments cm ON cm.post_id = p.id
+		    comments cm ON cm.post_id = p.id AND cm.removed_at IS NULL AND cm.deleted_at IS NULL
 		LEFT JOIN 
 		    user_communities uc ON uc.community_id = c.id AND uc.user_id = $1
+		LEFT JOIN
+		    community_flairs pf ON pf.id = p.flair_id
+		LEFT JOIN
+		    user_communities auc ON auc.community_id = p.community_id AND auc.user_id = p.user_id
+		LEFT JOIN
+		    community_flairs af ON af.id = auc.flair_id
 		LEFT JOIN 
 		    roles r ON r.id = uc.role_id
 		LEFT JOIN 
@@ -99,12 +149,13 @@ func (s *PostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*
 		    (SELECT post_id, SUM(value) AS total_votes FROM post_votes GROUP BY post_id) tv ON tv.post_id = p.id
 		LEFT JOIN
 		    (SELECT post_id, value AS user_vote FROM post_votes WHERE user_id = $1) uv ON uv.post_id = p.id
-		WHERE p.slug = $2
+		WHERE p.slug = $2 AND (p.status = 'published' OR (p.user_id = $1 AND p.deleted_at IS NULL))
 		GROUP BY 
 		    p.id, p.title, p.content, p.tags, p.slug, p.user_id, p.community_id, p.created_at,
 			c.id, c.name, c.slug, c.user_id, c.created_at,
 			u.id, u.name, u.username, u.bio, u.created_at,
 			r.id, r.name, r.level,
+			pf.id, af.id,
 			tm.num_members, tv.total_votes, uv.user_vote
 	`
 
@@ -112,15 +163,24 @@ func (s *PostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*
 	defer cancel()
 
 	post := PostDetails{}
+	var contentHTML sql.NullString
+	var flair, authorFlair nullFlair
 
 	err := s.db.QueryRowContext(ctx, query, userID, slug).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
+		&contentHTML,
 		pq.Array(&post.Tags),
 		&post.Slug,
 		&post.UserID,
 		&post.CommunityID,
+		&post.Pinned,
+		&post.Locked,
+		&post.Status,
+		&post.PublishAt,
+		&post.EditedAt,
+		&post.Tombstone,
 		&post.CreatedAt,
 		&post.Community.ID,
 		&post.Community.Name,
@@ -138,19 +198,43 @@ func (s *PostStore) GetBySlug(ctx context.Context, slug string, userID int64) (*
 		&post.User.Bio,
 		&post.User.AvatarID,
 		&post.User.CreatedAt,
+		&flair.ID,
+		&flair.Label,
+		&flair.TextColor,
+		&flair.BackgroundColor,
+		&authorFlair.ID,
+		&authorFlair.Label,
+		&authorFlair.TextColor,
+		&authorFlair.BackgroundColor,
 		&post.NumComments,
 		&post.Votes,
 		&post.UserVote,
 	)
 	if err != nil {
-		return nil, err
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	post.ContentHTML = renderedHTML(post.Content, contentHTML)
+	post.Flair = flair.overview()
+	post.AuthorFlair = authorFlair.overview()
+	post.mask()
+
+	if post.Tombstone != "" {
+		post.User = UserSummary{BaseUser: tombstoneAuthor}
 	}
 
 	return &post, nil
 }
 
+// Delete marks the post as deleted by its author, it stays behind as a
+// tombstone so its comments remain in place.
 func (s *PostStore) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM posts WHERE id = $1`
+	query := `UPDATE posts SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -176,48 +260,252 @@ func (s *PostStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (s *PostStore) Update(ctx context.Context, post *PostDetails) error {
+// Update saves the post, publishing a draft or scheduled post resets its
+// creation time so it surfaces as new. Edits to the title, content or tags of
+// a published post are kept as revisions, along with the original version.
+func (s *PostStore) Update(ctx context.Context, post *PostDetails, editorID int64, entry *ModLogEntry) error {
+	currentQuery := `SELECT status, title, content, tags FROM posts WHERE id = $1 FOR UPDATE`
+	originalQuery := `
+		INSERT INTO post_revisions (post_id, editor_id, title, content, tags, created_at)
+		SELECT id, user_id, title, content, tags, created_at FROM posts
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_id = $1)
+	`
+	revisionQuery := `
+		INSERT INTO post_revisions (post_id, editor_id, title, content, tags)
+		VALUES ($1, $2, $3, $4, $5)
+	`
 	query := `
-		UPDATE posts SET title = $1, content = $2, tags = $3, slug = $4 WHERE id = $5
+		UPDATE posts SET 
+			title = $1, content = $2, content_html = $3, tags = $4, slug = $5, flair_id = $6, status = $7, publish_at = $8,
+			created_at = CASE WHEN status <> 'published' AND $7 = 'published' THEN NOW() ELSE created_at END,
+			edited_at = CASE WHEN $10 THEN NOW() ELSE edited_at END
+		WHERE id = $9
+		RETURNING created_at, edited_at
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
-	defer cancel()
 
-	res, err := s.db.ExecContext(
-		ctx,
-		query,
-		post.Title,
-		post.Content,
-		pq.Array(post.Tags),
-		post.Slug,
-		post.ID,
-	)
-	if err != nil {
-		return err
-	}
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var current BasePost
+
+		err := tx.QueryRowContext(queryCtx, currentQuery, post.ID).Scan(
+			&current.Status,
+			&current.Title,
+			&current.Content,
+			pq.Array(&current.Tags),
+		)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
+		edited := current.Status == PostStatusPublished &&
+			(current.Title != post.Title || current.Content != post.Content || !slices.Equal(current.Tags, post.Tags))
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+		if edited {
+			if _, err = tx.ExecContext(queryCtx, originalQuery, post.ID); err != nil {
+				return err
+			}
 
-	return nil
+			_, err = tx.ExecContext(queryCtx, revisionQuery, post.ID, editorID, post.Title, post.Content, pq.Array(post.Tags))
+			if err != nil {
+				return err
+			}
+		}
+
+		post.ContentHTML = markdown.Render(post.Content)
+
+		err = tx.QueryRowContext(
+			queryCtx,
+			query,
+			post.Title,
+			post.Content,
+			post.ContentHTML,
+			pq.Array(post.Tags),
+			post.Slug,
+			post.flairID(),
+			post.Status,
+			post.PublishAt,
+			post.ID,
+			edited,
+		).Scan(
+			&post.CreatedAt,
+			&post.EditedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func (s *PostStore) SetLocked(ctx context.Context, id int64, locked bool, entry *ModLogEntry) error {
+	query := `UPDATE posts SET locked = $1 WHERE id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, locked, id)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
 }
 
+// SetPinned pins or unpins a post, at most maxPinned posts can be pinned in a
+// community at once.
+func (s *PostStore) SetPinned(ctx context.Context, id int64, pinned bool, maxPinned int, entry *ModLogEntry) error {
+	lockQuery := `
+		SELECT c.id FROM communities c
+		INNER JOIN posts p ON p.community_id = c.id
+		WHERE p.id = $1
+		FOR UPDATE OF c
+	`
+	countQuery := `SELECT COUNT(*) FROM posts WHERE community_id = $1 AND pinned_at IS NOT NULL AND id <> $2`
+	query := `UPDATE posts SET pinned_at = CASE WHEN $1 THEN COALESCE(pinned_at, NOW()) END WHERE id = $2`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if pinned {
+			// Pins in the same community are serialized on the community row,
+			// so two of them can't both see room under the limit.
+			var communityID int64
+			if err := tx.QueryRowContext(queryCtx, lockQuery, id).Scan(&communityID); err != nil {
+				switch err {
+				case sql.ErrNoRows:
+					return ErrNotFound
+				default:
+					return err
+				}
+			}
+
+			var count int
+			if err := tx.QueryRowContext(queryCtx, countQuery, communityID, id).Scan(&count); err != nil {
+				return err
+			}
+
+			if count >= maxPinned {
+				return ErrPinLimit
+			}
+		}
+
+		res, err := tx.ExecContext(queryCtx, query, pinned, id)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// GetCommunityPosts lists the posts of a community regardless of its
+// visibility, callers check the user may see the community first.
 func (s *PostStore) GetCommunityPosts(ctx context.Context, communityID, userID int64, q PaginatedPostsQuery) ([]PostSummary, Meta, error) {
-	return s.fetchPosts(ctx, userID, &communityID, q, false)
+	return s.fetchPosts(ctx, userID, &communityID, q, scopePublished)
 }
 
 func (s *PostStore) GetAll(ctx context.Context, userID int64, q PaginatedPostsQuery) ([]PostSummary, Meta, error) {
-	return s.fetchPosts(ctx, userID, nil, q, false)
+	return s.fetchPosts(ctx, userID, nil, q, scopePublished)
 }
 
 func (s *PostStore) GetUserFeed(ctx context.Context, userID int64, q PaginatedPostsQuery) ([]PostSummary, Meta, error) {
-	return s.fetchPosts(ctx, userID, nil, q, true)
+	return s.fetchPosts(ctx, userID, nil, q, scopeFeed)
+}
+
+// GetUserDrafts returns the user's own draft and scheduled posts.
+func (s *PostStore) GetUserDrafts(ctx context.Context, userID int64, q PaginatedPostsQuery) ([]PostSummary, Meta, error) {
+	return s.fetchPosts(ctx, userID, nil, q, scopeDrafts)
+}
+
+// PublishScheduled publishes every scheduled post that is due and returns
+// them so their authors can be notified. Posts of authors who are banned or
+// muted in the community stay scheduled until the restriction ends.
+func (s *PostStore) PublishScheduled(ctx context.Context) ([]PostSummary, error) {
+	query := `
+		WITH published AS (
+			UPDATE posts SET status = 'published', publish_at = NULL, created_at = NOW()
+			WHERE status = 'scheduled' AND publish_at <= NOW()
+				AND deleted_at IS NULL AND removed_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM community_bans b
+					WHERE b.community_id = posts.community_id AND b.user_id = posts.user_id
+						AND (b.expiry IS NULL OR b.expiry > NOW())
+				)
+			RETURNING id, title, slug, user_id, community_id, status, created_at
+		)
+		SELECT 
+			p.id, p.title, p.slug, p.user_id, p.community_id, p.status, p.created_at,
+			c.id, c.name, c.slug
+		FROM published p
+		INNER JOIN communities c ON c.id = p.community_id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	posts := []PostSummary{}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return posts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post := PostSummary{}
+
+		if err = rows.Scan(
+			&post.ID,
+			&post.Title,
+			&post.Slug,
+			&post.UserID,
+			&post.CommunityID,
+			&post.Status,
+			&post.CreatedAt,
+			&post.Community.ID,
+			&post.Community.Name,
+			&post.Community.Slug,
+		); err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+
+	return posts, nil
 }
 
 func (s *PostStore) Vote(ctx context.Context, value int, postID, userID int64) error {
@@ -239,13 +527,15 @@ func (s *PostStore) Vote(ctx context.Context, value int, postID, userID int64) e
 	return nil
 }
 
-func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *int64, q PaginatedPostsQuery, isFeed bool) ([]PostSummary, Meta, error) {
+func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *int64, q PaginatedPostsQuery, scope postScope) ([]PostSummary, Meta, error) {
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(`
 		SELECT 
-			p.id, p.title, p.content, p.tags, p.slug, p.user_id, p.community_id, p.created_at,
+			p.id, p.title, p.content, p.content_html, p.tags, p.slug, p.user_id, p.community_id, p.pinned_at IS NOT NULL AS pinned, p.locked, p.status, p.publish_at, p.edited_at, p.created_at,
 			c.id, c.name, c.slug, c.thumbnail_id,
 			u.id, u.name, u.username, u.avatar_id,
+			pf.id, pf.label, pf.text_color, pf.background_color,
+			af.id, af.label, af.text_color, af.background_color,
 			COALESCE(COUNT(cm.id), 0) AS num_comments,
 			COALESCE(tv.total_votes, 0) AS votes,
 			COALESCE(uv.user_vote, 0) AS user_vote,
@@ -259,13 +549,21 @@ func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *i
 		LEFT JOIN
 			user_communities uc ON uc.community_id = c.id AND uc.user_id = $1
 		LEFT JOIN
-			comments cm ON cm.post_id = p.id
+			comments cm ON cm.post_id = p.id AND cm.removed_at IS NULL AND cm.deleted_at IS NULL
+		LEFT JOIN
+			community_flairs pf ON pf.id = p.flair_id
+		LEFT JOIN
+			user_communities auc ON auc.community_id = p.community_id AND auc.user_id = p.user_id
+		LEFT JOIN
+			community_flairs af ON af.id = auc.flair_id
 		LEFT JOIN 
 		    (SELECT post_id, SUM(value) AS total_votes FROM post_votes GROUP BY post_id) tv ON tv.post_id = p.id
 		LEFT JOIN
 		    (SELECT post_id, value AS user_vote FROM post_votes WHERE user_id = $1) uv ON uv.post_id = p.id
 		WHERE 
 			(p.title ILIKE '%' || $2 || '%' OR p.content ILIKE '%' || $2 || '%')
+			AND p.removed_at IS NULL
+			AND p.deleted_at IS NULL
 	`)
 
 	args := []any{userID, q.Search}
@@ -282,35 +580,69 @@ func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *i
 		`)
 	}
 
+	// A single community is only listed once the caller has checked access to
+	// it, which staff have to private communities they haven't joined.
 	if communityID != nil {
 		queryBuilder.WriteString(`
 			AND c.id = $3
 		`)
 		args = append(args, *communityID)
+	} else {
+		queryBuilder.WriteString(`
+			AND (c.visibility <> 'private' OR uc.user_id IS NOT NULL)
+		`)
 	}
 
-	if isFeed {
+	switch scope {
+	case scopeDrafts:
 		queryBuilder.WriteString(`
-			AND uc.user_id IS NOT NULL
+			AND p.user_id = $1 AND p.status <> 'published'
 		`)
-	} 
+	case scopeFeed:
+		queryBuilder.WriteString(`
+			AND p.status = 'published' AND uc.user_id IS NOT NULL
+		`)
+	default:
+		queryBuilder.WriteString(`
+			AND p.status = 'published'
+		`)
+	}
+
+	if q.Flair != "" {
+		args = append(args, q.Flair)
+		queryBuilder.WriteString(`
+			AND LOWER(pf.label) = LOWER($` + fmt.Sprint(len(args)) + `)
+		`)
+	}
 
 	queryBuilder.WriteString(`
 		GROUP BY 
-	    	p.id, p.title, p.content, p.tags, p.slug, p.user_id, p.community_id, p.created_at,  
+	    	p.id, p.title, p.content, p.tags, p.slug, p.user_id, p.community_id, p.pinned_at, p.locked, p.status, p.publish_at, p.edited_at, p.created_at,  
 	    	c.id, c.name, c.slug, c.thumbnail_id, 
 	    	u.id, u.name, u.username, u.avatar_id,
+			pf.id, af.id,
 			tv.total_votes, uv.user_vote
 	`)
 
+	orderBy := []string{}
+
+	// Pinned posts stay on top of a community regardless of the view.
+	if communityID != nil {
+		orderBy = append(orderBy, "p.pinned_at DESC NULLS LAST")
+	}
+
 	viewFields := map[string]string{
 		"latest": "p.created_at",
 		"top": "votes",
 		"discussed": "num_comments",
 	}
 	if viewField, ok := viewFields[q.View]; ok {
+		orderBy = append(orderBy, viewField + ` ` + q.Sort)
+	}
+
+	if len(orderBy) > 0 {
 		queryBuilder.WriteString(`
-			ORDER BY ` + viewField + ` ` + q.Sort + ` 
+			ORDER BY ` + strings.Join(orderBy, ", ") + ` 
 		`)
 	}
 
@@ -330,15 +662,23 @@ func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *i
 
 	for rows.Next() {
 		post := PostSummary{}
+		var contentHTML sql.NullString
+		var flair, authorFlair nullFlair
 
 		if err = rows.Scan(
 			&post.ID,
 			&post.Title,
 			&post.Content,
+			&contentHTML,
 			pq.Array(&post.Tags),
 			&post.Slug,
 			&post.UserID,
 			&post.CommunityID,
+			&post.Pinned,
+			&post.Locked,
+			&post.Status,
+			&post.PublishAt,
+			&post.EditedAt,
 			&post.CreatedAt,
 			&post.Community.ID,
 			&post.Community.Name,
@@ -348,6 +688,14 @@ func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *i
 			&post.User.Name,
 			&post.User.Username,
 			&post.User.AvatarID,
+			&flair.ID,
+			&flair.Label,
+			&flair.TextColor,
+			&flair.BackgroundColor,
+			&authorFlair.ID,
+			&authorFlair.Label,
+			&authorFlair.TextColor,
+			&authorFlair.BackgroundColor,
 			&post.NumComments,
 			&post.Votes,
 			&post.UserVote,
@@ -356,6 +704,10 @@ func (s *PostStore) fetchPosts(ctx context.Context, userID int64, communityID *i
 			return posts, Meta{}, err
 		}
 
+		post.ContentHTML = renderedHTML(post.Content, contentHTML)
+		post.Flair = flair.overview()
+		post.AuthorFlair = authorFlair.overview()
+
 		posts = append(posts, post)
 	}
 
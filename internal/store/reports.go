@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+var ErrDuplicateReport = fmt.Errorf("you have already reported this")
+
+type Report struct {
+	ID          int64  `json:"id"`
+	ReporterID  *int64 `json:"reporterID"`
+	CommunityID int64  `json:"communityID"`
+	TargetType  string `json:"targetType"`
+	TargetID    int64  `json:"targetID"`
+	RuleID      *int64 `json:"ruleID"`
+	Reason      string `json:"reason"`
+	Details     string `json:"details"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type ReportStore struct {
+	db *sql.DB
+}
+
+func (s *ReportStore) Create(ctx context.Context, report *Report) error {
+	query := `
+		INSERT INTO reports (reporter_id, community_id, target_type, target_id, rule_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (reporter_id, target_type, target_id) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		report.ReporterID,
+		report.CommunityID,
+		report.TargetType,
+		report.TargetID,
+		report.RuleID,
+		report.Reason,
+		report.Details,
+	).Scan(
+		&report.ID,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrDuplicateReport
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Revision is a version of a post or comment, comments have no title or
+// tags. The first revision is the content as originally posted.
+type Revision struct {
+	ID        int64        `json:"id"`
+	Title     string       `json:"title,omitempty"`
+	Content   string       `json:"content"`
+	Tags      []string     `json:"tags,omitempty"`
+	Editor    UserOverview `json:"editor"`
+	CreatedAt string       `json:"createdAt"`
+	// PreviousTitle and PreviousContent come from the revision before this
+	// one, even when it is on another page, and are nil for the original.
+	PreviousTitle   *string `json:"-"`
+	PreviousContent *string `json:"-"`
+}
+
+type RevisionStore struct {
+	db *sql.DB
+}
+
+func (s *RevisionStore) GetPostRevisions(ctx context.Context, postID int64, q PaginatedRevisionsQuery) ([]Revision, Meta, error) {
+	query := `
+		SELECT
+			r.id, r.title, r.content, r.tags, r.created_at,
+			LAG(r.title) OVER w, LAG(r.content) OVER w,
+			COALESCE(u.id, 0), COALESCE(u.name, ''), COALESCE(u.username, '[deleted]'), COALESCE(u.avatar_id, ''),
+			COUNT(*) OVER() AS total
+		FROM post_revisions r
+		LEFT JOIN users u ON u.id = r.editor_id
+		WHERE r.post_id = $1
+		WINDOW w AS (ORDER BY r.created_at, r.id)
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`
+
+	return s.fetchRevisions(ctx, query, postID, q, func(rows *sql.Rows, revision *Revision, total *int) error {
+		return rows.Scan(
+			&revision.ID,
+			&revision.Title,
+			&revision.Content,
+			pq.Array(&revision.Tags),
+			&revision.CreatedAt,
+			&revision.PreviousTitle,
+			&revision.PreviousContent,
+			&revision.Editor.ID,
+			&revision.Editor.Name,
+			&revision.Editor.Username,
+			&revision.Editor.AvatarID,
+			total,
+		)
+	})
+}
+
+func (s *RevisionStore) GetCommentRevisions(ctx context.Context, commentID int64, q PaginatedRevisionsQuery) ([]Revision, Meta, error) {
+	query := `
+		SELECT
+			r.id, r.content, r.created_at,
+			LAG(r.content) OVER w,
+			COALESCE(u.id, 0), COALESCE(u.name, ''), COALESCE(u.username, '[deleted]'), COALESCE(u.avatar_id, ''),
+			COUNT(*) OVER() AS total
+		FROM comment_revisions r
+		LEFT JOIN users u ON u.id = r.editor_id
+		WHERE r.comment_id = $1
+		WINDOW w AS (ORDER BY r.created_at, r.id)
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`
+
+	return s.fetchRevisions(ctx, query, commentID, q, func(rows *sql.Rows, revision *Revision, total *int) error {
+		return rows.Scan(
+			&revision.ID,
+			&revision.Content,
+			&revision.CreatedAt,
+			&revision.PreviousContent,
+			&revision.Editor.ID,
+			&revision.Editor.Name,
+			&revision.Editor.Username,
+			&revision.Editor.AvatarID,
+			total,
+		)
+	})
+}
+
+func (s *RevisionStore) fetchRevisions(ctx context.Context, query string, targetID int64, q PaginatedRevisionsQuery, scan func(*sql.Rows, *Revision, *int) error) ([]Revision, Meta, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	revisions := []Revision{}
+	var totalCount int
+
+	rows, err := s.db.QueryContext(ctx, query, targetID, q.Limit, q.Offset)
+	if err != nil {
+		return revisions, Meta{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision Revision
+
+		if err = scan(rows, &revision, &totalCount); err != nil {
+			return revisions, Meta{}, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return revisions, Meta{}, err
+	}
+
+	meta := Meta{
+		TotalCount:  totalCount,
+		TotalPages:  (totalCount + q.Limit - 1) / q.Limit,
+		CurrentPage: q.Offset/q.Limit + 1,
+		Offset:      q.Offset,
+		Limit:       q.Limit,
+	}
+
+	return revisions, meta, nil
+}
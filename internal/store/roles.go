@@ -12,7 +12,7 @@ type RoleStore struct {
 type Role struct {
 	ID    int64  `json:"id"`
 	Name  string `json:"name"`
-	Level string `json:"level"`
+	Level int    `json:"level"`
 }
 
 func (s *RoleStore) GetByName(ctx context.Context, name string) (*Role, error) {
@@ -49,3 +49,30 @@ func (s *RoleStore) GetCommunityRole(ctx context.Context, userID, communityID in
 
 	return role, nil
 }
+
+func (s *RoleStore) GetHighestCommunityRole(ctx context.Context, userID int64) (*Role, error) {
+	query := `
+		SELECT r.id, r.name, r.level
+		FROM roles r
+		INNER JOIN user_communities uc ON uc.role_id = r.id
+		WHERE uc.user_id = $1
+		ORDER BY r.level DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	role := &Role{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&role.ID, &role.Name, &role.Level)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return role, nil
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Rule struct {
+	ID          int64  `json:"id"`
+	CommunityID int64  `json:"communityID"`
+	Position    int    `json:"position"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type RuleStore struct {
+	db *sql.DB
+}
+
+func (s *RuleStore) GetCommunityRules(ctx context.Context, communityID int64) ([]Rule, error) {
+	query := `
+		SELECT id, community_id, position, title, description
+		FROM community_rules
+		WHERE community_id = $1
+		ORDER BY position ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rules := []Rule{}
+
+	rows, err := s.db.QueryContext(ctx, query, communityID)
+	if err != nil {
+		return rules, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule Rule
+
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.CommunityID,
+			&rule.Position,
+			&rule.Title,
+			&rule.Description,
+		); err != nil {
+			return rules, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return rules, err
+	}
+
+	return rules, nil
+}
+
+// Replace stores rules in the given order. Rules with an ID are updated in
+// place so reports citing them keep their reference, rules missing from the
+// list are deleted.
+func (s *RuleStore) Replace(ctx context.Context, communityID int64, rules []Rule, entry *ModLogEntry) error {
+	updateQuery := `
+		UPDATE community_rules SET position = $1, title = $2, description = $3
+		WHERE id = $4 AND community_id = $5
+	`
+	insertQuery := `
+		INSERT INTO community_rules (community_id, position, title, description)
+		VALUES ($1, $2, $3, $4) RETURNING id
+	`
+	deleteQuery := `DELETE FROM community_rules WHERE community_id = $1 AND NOT (id = ANY($2))`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		ids := []int64{}
+
+		for i := range rules {
+			rules[i].CommunityID = communityID
+			rules[i].Position = i + 1
+
+			if rules[i].ID == 0 {
+				err := tx.QueryRowContext(
+					ctx,
+					insertQuery,
+					communityID,
+					rules[i].Position,
+					rules[i].Title,
+					rules[i].Description,
+				).Scan(&rules[i].ID)
+				if err != nil {
+					return err
+				}
+			} else {
+				res, err := tx.ExecContext(
+					ctx,
+					updateQuery,
+					rules[i].Position,
+					rules[i].Title,
+					rules[i].Description,
+					rules[i].ID,
+					communityID,
+				)
+				if err != nil {
+					return err
+				}
+
+				rows, err := res.RowsAffected()
+				if err != nil {
+					return err
+				}
+
+				if rows == 0 {
+					return ErrNotFound
+				}
+			}
+
+			ids = append(ids, rules[i].ID)
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteQuery, communityID, pq.Array(ids)); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+type Session struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"userID"`
+	UserAgent  string `json:"userAgent"`
+	IP         string `json:"ip"`
+	Current    bool   `json:"current"`
+	Expiry     string `json:"expiry"`
+	LastSeenAt string `json:"lastSeenAt"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+var sessionTouchInterval = time.Minute
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+func (s *SessionStore) Create(ctx context.Context, session *Session, token string, exp time.Duration) error {
+	query := `
+		INSERT INTO sessions (user_id, token, expiry, user_agent, ip)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, expiry, last_seen_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		session.UserID,
+		token,
+		time.Now().Add(exp),
+		session.UserAgent,
+		session.IP,
+	).Scan(
+		&session.ID,
+		&session.Expiry,
+		&session.LastSeenAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionStore) GetByID(ctx context.Context, id int64) (*Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, expiry, last_seen_at, created_at
+		FROM sessions
+		WHERE id = $1 AND revoked_at IS NULL AND expiry > $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	session := &Session{}
+	err := s.db.QueryRowContext(ctx, query, id, time.Now()).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IP,
+		&session.Expiry,
+		&session.LastSeenAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// Rotate swaps the refresh token of a session for a new one. A token that was
+// already rotated out can only be presented again by someone who copied it,
+// so its reuse revokes the whole session.
+func (s *SessionStore) Rotate(ctx context.Context, token, newToken string, exp time.Duration) (*Session, error) {
+	query := `
+		UPDATE sessions
+		SET token = $1, expiry = $2
+		WHERE token = $3 AND revoked_at IS NULL AND expiry > $4
+		RETURNING id, user_id, user_agent, ip, expiry, last_seen_at, created_at
+	`
+	rotatedQuery := `INSERT INTO rotated_session_tokens (token, session_id) VALUES ($1, $2)`
+	reuseQuery := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE id = (SELECT session_id FROM rotated_session_tokens WHERE token = $2) AND revoked_at IS NULL
+	`
+
+	hash := sha256.Sum256([]byte(token))
+	hashToken := hex.EncodeToString(hash[:])
+
+	session := &Session{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		now := time.Now()
+
+		err := tx.QueryRowContext(ctx, query, newToken, now.Add(exp), hashToken, now).Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.Expiry,
+			&session.LastSeenAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				_, err = tx.ExecContext(ctx, reuseQuery, now, hashToken)
+				return err
+			default:
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, rotatedQuery, hashToken, session.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The token was unknown or reused, either way it doesn't refresh anything.
+	if session.ID == 0 {
+		return nil, ErrNotFound
+	}
+
+	return session, nil
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, id, userID int64) error {
+	query := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SessionStore) RevokeAll(ctx context.Context, userID int64) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionStore) GetUserSessions(ctx context.Context, userID int64) ([]Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, expiry, last_seen_at, created_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expiry > $2
+		ORDER BY last_seen_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	sessions := []Session{}
+
+	rows, err := s.db.QueryContext(ctx, query, userID, time.Now())
+	if err != nil {
+		return sessions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session Session
+
+		if err = rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.Expiry,
+			&session.LastSeenAt,
+			&session.CreatedAt,
+		); err != nil {
+			return sessions, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return sessions, err
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionStore) Touch(ctx context.Context, id int64, ip string) error {
+	query := `
+		UPDATE sessions SET last_seen_at = $1, ip = $2
+		WHERE id = $3 AND last_seen_at < $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	now := time.Now()
+
+	_, err := s.db.ExecContext(ctx, query, now, ip, id, now.Add(-sessionTouchInterval))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionStore) RevokeOthers(ctx context.Context, userID, sessionID int64) error {
+	query := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, time.Now(), userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func revokeSessions(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func revokeOtherSessions(ctx context.Context, tx *sql.Tx, userID, sessionID int64) error {
+	query := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
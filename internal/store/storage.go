@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -16,8 +17,8 @@ type Storage struct {
 	Communities interface {
 		Create(context.Context, *CommunityDetails) error
 		GetBySlug(context.Context, string, int64) (*CommunityDetails, error)
-		Delete(context.Context, int64) error
-		Update(context.Context, *CommunityDetails) error
+		Delete(context.Context, int64, *ModLogEntry) error
+		Update(context.Context, *CommunityDetails, *ModLogEntry) error
 		Join(context.Context, int64, int64, string) error
 		Leave(context.Context, int64, int64) error
 		GetAll(context.Context, int64, PaginatedCommunitiesQuery) ([]CommunitySummary, error)
@@ -27,16 +28,20 @@ type Storage struct {
 		Create(context.Context, *PostDetails) error
 		GetBySlug(context.Context, string, int64) (*PostDetails, error)
 		Delete(context.Context, int64) error
-		Update(context.Context, *PostDetails) error
+		Update(context.Context, *PostDetails, int64, *ModLogEntry) error
 		GetCommunityPosts(context.Context, int64, int64, PaginatedPostsQuery) ([]PostSummary, Meta, error)
 		GetAll(context.Context, int64, PaginatedPostsQuery) ([]PostSummary, Meta, error)
 		GetUserFeed(context.Context, int64, PaginatedPostsQuery) ([]PostSummary, Meta, error)
+		GetUserDrafts(context.Context, int64, PaginatedPostsQuery) ([]PostSummary, Meta, error)
+		PublishScheduled(context.Context) ([]PostSummary, error)
 		Vote(context.Context, int, int64, int64) error
+		SetLocked(context.Context, int64, bool, *ModLogEntry) error
+		SetPinned(context.Context, int64, bool, int, *ModLogEntry) error
 	}
 	Comments interface {
 		Create(context.Context, *Comment) error
 		GetByID(context.Context, int64, int64) (*Comment, error)
-		Update(context.Context, *Comment) error
+		Update(context.Context, *Comment, int64, *ModLogEntry) error
 		Delete(context.Context, int64) error
 		GetByPostID(context.Context, int64, int64) ([]Comment, error)
 		Vote(context.Context, int, int64, int64) error
@@ -50,11 +55,125 @@ type Storage struct {
 		Update(context.Context, *UserDetails) error
 		CreateAndInvite(context.Context, *UserDetails, string, time.Duration) error
 		Activate(context.Context, string) error
+		ReplaceInvitation(context.Context, string, time.Duration, int64) error
+		DeleteInactive(context.Context, time.Duration) (int64, error)
 		CreatePasswordReset(context.Context, string, time.Duration, int64) error
 		ResetPassword(context.Context, string, []byte) error
+		CreateMagicLink(context.Context, string, time.Duration, int64) error
+		ConsumeMagicLink(context.Context, string) (int64, error)
+		ChangePassword(context.Context, int64, int64, []byte) error
+		CreateEmailChange(context.Context, string, time.Duration, int64, int64, string) error
+		ConfirmEmailChange(context.Context, string) error
+		SetTOTPSecret(context.Context, int64, string) error
+		EnableTOTP(context.Context, int64, []string) error
+		DisableTOTP(context.Context, int64) error
+		UseRecoveryCode(context.Context, int64, string) error
+		UseTOTPStep(context.Context, int64, int64) error
+		GetByIdentity(context.Context, string, string) (*UserDetails, error)
+		LinkIdentity(context.Context, int64, string, string, []byte) error
+		CreateWithIdentity(context.Context, *UserDetails, string, string) error
+	}
+	Sessions interface {
+		Create(context.Context, *Session, string, time.Duration) error
+		GetByID(context.Context, int64) (*Session, error)
+		Rotate(context.Context, string, string, time.Duration) (*Session, error)
+		Revoke(context.Context, int64, int64) error
+		RevokeAll(context.Context, int64) error
+		RevokeOthers(context.Context, int64, int64) error
+		GetUserSessions(context.Context, int64) ([]Session, error)
+		Touch(context.Context, int64, string) error
+	}
+	Tokens interface {
+		Create(context.Context, *AccessToken, string, *time.Duration) error
+		GetByToken(context.Context, string) (*AccessToken, error)
+		GetUserTokens(context.Context, int64) ([]AccessToken, error)
+		Touch(context.Context, int64) error
+		Delete(context.Context, int64, int64) error
+	}
+	Members interface {
+		GetCommunityMembers(context.Context, int64, PaginatedMembersQuery) ([]Member, error)
+		GetByUsername(context.Context, int64, string) (*Member, error)
+		UpdateRole(context.Context, int64, int64, string, *ModLogEntry) error
+		Remove(context.Context, int64, int64, *ModLogEntry) error
+	}
+	Bans interface {
+		Create(context.Context, *Ban, *time.Duration, *ModLogEntry) error
+		GetActive(context.Context, int64, int64) (*Ban, error)
+		GetCommunityBans(context.Context, int64) ([]Ban, error)
+		Delete(context.Context, int64, int64, *ModLogEntry) error
+	}
+	JoinRequests interface {
+		Create(context.Context, *JoinRequest) error
+		GetCommunityRequests(context.Context, int64) ([]JoinRequest, error)
+		Approve(context.Context, int64, int64, *ModLogEntry) error
+		Delete(context.Context, int64, int64, *ModLogEntry) error
+	}
+	Invites interface {
+		Create(context.Context, *Invite, string, *time.Duration, *ModLogEntry) error
+		GetCommunityInvites(context.Context, int64) ([]Invite, error)
+		Accept(context.Context, string, int64, int64) error
+		Delete(context.Context, int64, int64, *ModLogEntry) error
+	}
+	Transfers interface {
+		Create(context.Context, *Transfer, time.Duration) error
+		Get(context.Context, int64) (*Transfer, error)
+		Delete(context.Context, int64) error
+		Accept(context.Context, int64, int64) error
+		Force(context.Context, int64, int64, *ModLogEntry) error
+	}
+	Rules interface {
+		GetCommunityRules(context.Context, int64) ([]Rule, error)
+		Replace(context.Context, int64, []Rule, *ModLogEntry) error
+	}
+	Reports interface {
+		Create(context.Context, *Report) error
+	}
+	ModQueue interface {
+		GetQueue(context.Context, *int64, PaginatedQueueQuery) ([]QueueItem, Meta, error)
+		GetItem(context.Context, string, int64) (*QueueItem, error)
+		Approve(context.Context, string, int64, int64, *ModLogEntry) error
+		Remove(context.Context, string, int64, *int64, *ModLogEntry) error
+		RemoveAndBan(context.Context, string, int64, *Ban, *time.Duration, *ModLogEntry, *ModLogEntry) error
+	}
+	AutoMod interface {
+		GetRules(context.Context, int64) (json.RawMessage, error)
+		SaveRules(context.Context, int64, int64, json.RawMessage, *ModLogEntry) error
+		GetAuthorStanding(context.Context, int64) (*AuthorStanding, error)
+		GetRecentPosts(context.Context, int64, int) ([]AutoModPost, error)
+	}
+	Tombstones interface {
+		Restore(context.Context, string, int64, *ModLogEntry) error
+		Purge(context.Context, time.Duration) (int64, []string, error)
+	}
+	Media interface {
+		GetPostMedia(context.Context, int64) ([]PostMedia, error)
+		GetByID(context.Context, int64, int64) (*PostMedia, error)
+		Create(context.Context, int64, []PostMedia, int) error
+		Update(context.Context, *PostMedia) error
+		Reorder(context.Context, int64, []int64) error
+		Delete(context.Context, int64, int64) (string, error)
+	}
+	Content interface {
+		RenderPending(context.Context, int) (int64, error)
+	}
+	Revisions interface {
+		GetPostRevisions(context.Context, int64, PaginatedRevisionsQuery) ([]Revision, Meta, error)
+		GetCommentRevisions(context.Context, int64, PaginatedRevisionsQuery) ([]Revision, Meta, error)
+	}
+	Flairs interface {
+		GetCommunityFlairs(context.Context, int64, string) ([]Flair, error)
+		GetByID(context.Context, int64, int64) (*Flair, error)
+		Create(context.Context, *Flair, *ModLogEntry) error
+		Update(context.Context, *Flair, *ModLogEntry) error
+		Delete(context.Context, int64, int64, *ModLogEntry) error
+		SetUserFlair(context.Context, int64, int64, *int64, *ModLogEntry) error
+	}
+	ModLog interface {
+		GetCommunityLog(context.Context, int64, PaginatedModLogQuery) ([]ModLogEntry, Meta, error)
 	}
 	Roles interface {
 		GetByName(context.Context, string) (*Role, error)
+		GetHighestCommunityRole(context.Context, int64) (*Role, error)
 	}
 	Common interface {
 		GenerateUniqueSlug(context.Context, string, string) (string, error)
@@ -75,6 +194,57 @@ func NewStorage(db *sql.DB) Storage {
 		Users: &UserStore{
 			db: db,
 		},
+		Sessions: &SessionStore{
+			db: db,
+		},
+		Tokens: &TokenStore{
+			db: db,
+		},
+		Members: &MemberStore{
+			db: db,
+		},
+		Bans: &BanStore{
+			db: db,
+		},
+		JoinRequests: &JoinRequestStore{
+			db: db,
+		},
+		Invites: &InviteStore{
+			db: db,
+		},
+		Transfers: &TransferStore{
+			db: db,
+		},
+		Rules: &RuleStore{
+			db: db,
+		},
+		Reports: &ReportStore{
+			db: db,
+		},
+		ModQueue: &ModQueueStore{
+			db: db,
+		},
+		AutoMod: &AutoModStore{
+			db: db,
+		},
+		Tombstones: &TombstoneStore{
+			db: db,
+		},
+		Media: &MediaStore{
+			db: db,
+		},
+		Content: &ContentStore{
+			db: db,
+		},
+		Revisions: &RevisionStore{
+			db: db,
+		},
+		Flairs: &FlairStore{
+			db: db,
+		},
+		ModLog: &ModLogStore{
+			db: db,
+		},
 		Roles: &RoleStore{
 			db: db,
 		},
@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type AccessToken struct {
+	ID         int64    `json:"id"`
+	UserID     int64    `json:"userID"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+	Expiry     *string  `json:"expiry"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type TokenStore struct {
+	db *sql.DB
+}
+
+func (s *TokenStore) Create(ctx context.Context, token *AccessToken, hashToken string, exp *time.Duration) error {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token, scopes, expiry)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, expiry, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var expiry *time.Time
+	if exp != nil {
+		t := time.Now().Add(*exp)
+		expiry = &t
+	}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.Name,
+		hashToken,
+		pq.Array(token.Scopes),
+		expiry,
+	).Scan(
+		&token.ID,
+		&token.Expiry,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *TokenStore) GetByToken(ctx context.Context, plainToken string) (*AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, last_used_at, expiry, created_at
+		FROM personal_access_tokens
+		WHERE token = $1 AND (expiry IS NULL OR expiry > $2)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	token := &AccessToken{}
+	err := s.db.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		pq.Array(&token.Scopes),
+		&token.LastUsedAt,
+		&token.Expiry,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
+func (s *TokenStore) GetUserTokens(ctx context.Context, userID int64) ([]AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, last_used_at, expiry, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	tokens := []AccessToken{}
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return tokens, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token AccessToken
+
+		if err = rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			pq.Array(&token.Scopes),
+			&token.LastUsedAt,
+			&token.Expiry,
+			&token.CreatedAt,
+		); err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return tokens, err
+	}
+
+	return tokens, nil
+}
+
+func (s *TokenStore) Touch(ctx context.Context, id int64) error {
+	query := `
+		UPDATE personal_access_tokens SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	now := time.Now()
+
+	_, err := s.db.ExecContext(ctx, query, now, id, now.Add(-sessionTouchInterval))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *TokenStore) Delete(ctx context.Context, id, userID int64) error {
+	query := `DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func deleteAccessTokens(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `DELETE FROM personal_access_tokens WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	TombstoneDeleted = "deleted"
+	TombstoneRemoved = "removed"
+)
+
+// tombstoneColumn tells apart content deleted by its author from content
+// removed by a moderator, it's empty for visible content.
+func tombstoneColumn(alias string) string {
+	return `CASE
+		WHEN ` + alias + `.removed_at IS NOT NULL THEN 'removed'
+		WHEN ` + alias + `.deleted_at IS NOT NULL THEN 'deleted'
+		ELSE '' END`
+}
+
+// tombstoneAuthor replaces the author of deleted or removed content.
+var tombstoneAuthor = BaseUser{Username: "[deleted]"}
+
+func (p *BasePost) mask() {
+	if p.Tombstone == "" {
+		return
+	}
+
+	p.Title = "[" + p.Tombstone + "]"
+	p.Content = "[" + p.Tombstone + "]"
+	p.ContentHTML = "<p>" + p.Content + "</p>"
+	p.Tags = []string{}
+	p.UserID = 0
+	p.Flair = nil
+	p.AuthorFlair = nil
+}
+
+func (c *Comment) mask() {
+	if c.Tombstone == "" {
+		return
+	}
+
+	c.Content = "[" + c.Tombstone + "]"
+	c.ContentHTML = "<p>" + c.Content + "</p>"
+	c.UserID = 0
+	c.User = UserOverview{BaseUser: tombstoneAuthor}
+	c.AuthorFlair = nil
+}
+
+type TombstoneStore struct {
+	db *sql.DB
+}
+
+// Restore brings back deleted or removed content, unless it has already been
+// purged.
+func (s *TombstoneStore) Restore(ctx context.Context, targetType string, targetID int64, entry *ModLogEntry) error {
+	table, ok := removableTables[targetType]
+	if !ok {
+		return ErrNotFound
+	}
+
+	query := `
+		UPDATE ` + table + ` SET deleted_at = NULL, removed_at = NULL, removed_by = NULL
+		WHERE id = $1 AND (deleted_at IS NOT NULL OR removed_at IS NOT NULL) AND content <> ''
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, query, targetID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+// Purge permanently deletes content that has been deleted or removed for
+// longer than olderThan. Tombstones still holding up replies are kept as
+// placeholders with their content and revisions erased, they are deleted on a
+// later run once the replies are gone. The galleries of purged posts go with
+// them and their file ids are returned to delete from storage.
+func (s *TombstoneStore) Purge(ctx context.Context, olderThan time.Duration) (int64, []string, error) {
+	var (
+		purged  int64
+		fileIDs []string
+	)
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		cutoff := time.Now().Add(-olderThan)
+
+		var err error
+		if fileIDs, err = deleteExpiredMedia(ctx, tx, cutoff); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		expiredComments := `SELECT id FROM comments WHERE COALESCE(deleted_at, removed_at) < $1`
+		expiredPosts := `SELECT id FROM posts WHERE COALESCE(deleted_at, removed_at) < $1`
+
+		deletes := []string{
+			`DELETE FROM comments c WHERE c.id IN (` + expiredComments + `) AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)`,
+			`DELETE FROM posts p WHERE p.id IN (` + expiredPosts + `) AND NOT EXISTS (SELECT 1 FROM comments cm WHERE cm.post_id = p.id)`,
+		}
+
+		for _, query := range deletes {
+			res, err := tx.ExecContext(ctx, query, cutoff)
+			if err != nil {
+				return err
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			purged += rows
+		}
+
+		erasures := []string{
+			`DELETE FROM comment_revisions WHERE comment_id IN (` + expiredComments + `)`,
+			`UPDATE comments SET content = '', content_html = '' WHERE id IN (` + expiredComments + `) AND content <> ''`,
+			`DELETE FROM post_revisions WHERE post_id IN (` + expiredPosts + `)`,
+			`UPDATE posts SET title = '', content = '', content_html = '', tags = NULL WHERE id IN (` + expiredPosts + `) AND content <> ''`,
+		}
+
+		for _, query := range erasures {
+			if _, err := tx.ExecContext(ctx, query, cutoff); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return purged, fileIDs, nil
+}
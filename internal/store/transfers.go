@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Transfer struct {
+	CommunityID int64        `json:"communityID"`
+	FromUserID  int64        `json:"fromUserID"`
+	ToUser      UserOverview `json:"toUser"`
+	Expiry      string       `json:"expiry"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+type TransferStore struct {
+	db *sql.DB
+}
+
+func (s *TransferStore) Create(ctx context.Context, transfer *Transfer, exp time.Duration) error {
+	query := `
+		INSERT INTO community_transfers (community_id, from_user_id, to_user_id, expiry)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (community_id) DO UPDATE SET
+			from_user_id = EXCLUDED.from_user_id,
+			to_user_id = EXCLUDED.to_user_id,
+			expiry = EXCLUDED.expiry,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING expiry, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		transfer.CommunityID,
+		transfer.FromUserID,
+		transfer.ToUser.ID,
+		time.Now().Add(exp),
+	).Scan(
+		&transfer.Expiry,
+		&transfer.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *TransferStore) Get(ctx context.Context, communityID int64) (*Transfer, error) {
+	query := `
+		SELECT t.community_id, t.from_user_id, t.expiry, t.created_at, u.id, u.name, u.username, u.avatar_id
+		FROM community_transfers t
+		INNER JOIN users u ON u.id = t.to_user_id
+		WHERE t.community_id = $1 AND t.expiry > $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	transfer := &Transfer{}
+	err := s.db.QueryRowContext(ctx, query, communityID, time.Now()).Scan(
+		&transfer.CommunityID,
+		&transfer.FromUserID,
+		&transfer.Expiry,
+		&transfer.CreatedAt,
+		&transfer.ToUser.ID,
+		&transfer.ToUser.Name,
+		&transfer.ToUser.Username,
+		&transfer.ToUser.AvatarID,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return transfer, nil
+}
+
+func (s *TransferStore) Delete(ctx context.Context, communityID int64) error {
+	query := `DELETE FROM community_transfers WHERE community_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, communityID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Accept completes a pending transfer nominated to the user, the previous
+// owner stays on as an admin.
+func (s *TransferStore) Accept(ctx context.Context, communityID, userID int64) error {
+	query := `
+		DELETE FROM community_transfers
+		WHERE community_id = $1 AND to_user_id = $2 AND expiry > $3
+		RETURNING from_user_id
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var fromUserID int64
+		err := tx.QueryRowContext(queryCtx, query, communityID, userID, time.Now()).Scan(&fromUserID)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		return transferOwnership(ctx, tx, communityID, fromUserID, userID, "admin")
+	})
+}
+
+// Force hands the community over to the user without the owner's consent,
+// demoting the previous owner to a regular member.
+func (s *TransferStore) Force(ctx context.Context, communityID, userID int64, entry *ModLogEntry) error {
+	ownerQuery := `SELECT user_id FROM communities WHERE id = $1 FOR UPDATE`
+	deleteQuery := `DELETE FROM community_transfers WHERE community_id = $1`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var ownerID int64
+		if err := tx.QueryRowContext(queryCtx, ownerQuery, communityID).Scan(&ownerID); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(queryCtx, deleteQuery, communityID); err != nil {
+			return err
+		}
+
+		if err := transferOwnership(ctx, tx, communityID, ownerID, userID, "member"); err != nil {
+			return err
+		}
+
+		return createModLogEntry(ctx, tx, entry)
+	})
+}
+
+func transferOwnership(ctx context.Context, tx *sql.Tx, communityID, fromUserID, toUserID int64, previousOwnerRole string) error {
+	ownerQuery := `UPDATE communities SET user_id = $1 WHERE id = $2`
+	newOwnerQuery := `
+		INSERT INTO user_communities (user_id, community_id, role_id)
+		VALUES ($1, $2, (SELECT id FROM roles WHERE name = 'admin'))
+		ON CONFLICT (user_id, community_id) DO UPDATE SET role_id = EXCLUDED.role_id
+	`
+	previousOwnerQuery := `
+		UPDATE user_communities SET role_id = (SELECT id FROM roles WHERE name = $1)
+		WHERE user_id = $2 AND community_id = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, ownerQuery, toUserID, communityID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, newOwnerQuery, toUserID, communityID); err != nil {
+		return err
+	}
+
+	if fromUserID == toUserID {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, previousOwnerQuery, previousOwnerRole, fromUserID, communityID)
+	return err
+}
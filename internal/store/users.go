@@ -36,12 +36,14 @@ type UserSummary struct {
 
 type UserDetails struct {
 	BaseUser
-	Bio       string   `json:"bio"`
-	Email     string   `json:"email"`
-	Password  password `json:"-"`
-	Role      Role     `json:"role"`
-	IsActive  bool     `json:"isActive"`
-	CreatedAt string   `json:"createdAt"`
+	Bio        string   `json:"bio"`
+	Email      string   `json:"email"`
+	Password   password `json:"-"`
+	Role       Role     `json:"role"`
+	IsActive   bool     `json:"isActive"`
+	MFAEnabled bool     `json:"mfaEnabled"`
+	TOTPSecret string   `json:"-"`
+	CreatedAt  string   `json:"createdAt"`
 }
 
 type password struct {
@@ -124,6 +126,7 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*UserDetails, error)
 	query := `
 		SELECT 
 		    u.id, u.name, u.username, u.email, u.bio, u.avatar_id, u.is_active, u.created_at,
+		    u.totp_enabled, COALESCE(u.totp_secret, ''),
 		    r.id, r.name, r.level
 		FROM users u
 		INNER JOIN roles r ON r.id = u.role_id
@@ -135,7 +138,7 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*UserDetails, error)
 		ctx,
 		query,
 		[]any{id},
-		[]any{&user.ID, &user.Name, &user.Username, &user.Email, &user.Bio, &user.AvatarID, &user.IsActive, &user.CreatedAt, &user.Role.ID, &user.Role.Name, &user.Role.Level},
+		[]any{&user.ID, &user.Name, &user.Username, &user.Email, &user.Bio, &user.AvatarID, &user.IsActive, &user.CreatedAt, &user.MFAEnabled, &user.TOTPSecret, &user.Role.ID, &user.Role.Name, &user.Role.Level},
 	); err != nil {
 		return nil, err
 	}
@@ -144,14 +147,14 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*UserDetails, error)
 }
 
 func (s *UserStore) GetByEmail(ctx context.Context, email string) (*UserDetails, error) {
-	query := `SELECT id, name, username, password, email, is_active, created_at FROM users WHERE email = $1`
+	query := `SELECT id, name, username, password, email, is_active, totp_enabled, created_at FROM users WHERE email = $1`
 
 	user := &UserDetails{}
 	if err := s.fetchUser(
 		ctx,
 		query,
 		[]any{email},
-		[]any{&user.ID, &user.Name, &user.Username, &user.Password.Hash, &user.Email, &user.IsActive, &user.CreatedAt},
+		[]any{&user.ID, &user.Name, &user.Username, &user.Password.Hash, &user.Email, &user.IsActive, &user.MFAEnabled, &user.CreatedAt},
 	); err != nil {
 		return nil, err
 	}
@@ -221,6 +224,50 @@ func (s *UserStore) Activate(ctx context.Context, token string) error {
 	})
 }
 
+func (s *UserStore) ReplaceInvitation(ctx context.Context, token string, invitationExp time.Duration, userID int64) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.deleteUserInvitation(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.createInvitation(ctx, tx, token, invitationExp, userID)
+	})
+}
+
+func (s *UserStore) DeleteInactive(ctx context.Context, olderThan time.Duration) (int64, error) {
+	inactiveQuery := `SELECT id FROM users WHERE is_active = false AND created_at < $1`
+
+	var deleted int64
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		cutoff := time.Now().Add(-olderThan)
+
+		queries := []string{
+			`DELETE FROM user_invitations WHERE user_id IN (` + inactiveQuery + `)`,
+			`DELETE FROM password_resets WHERE user_id IN (` + inactiveQuery + `)`,
+		}
+
+		for _, query := range queries {
+			if _, err := tx.ExecContext(ctx, query, cutoff); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id IN (`+inactiveQuery+`)`, cutoff)
+		if err != nil {
+			return err
+		}
+
+		deleted, err = res.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
 func (s *UserStore) CreatePasswordReset(ctx context.Context, token string, exp time.Duration, userID int64) error {
 	query := `INSERT INTO password_resets (token, user_id, expiry) VALUES($1, $2, $3)`
 
@@ -246,10 +293,422 @@ func (s *UserStore) ResetPassword(ctx context.Context, token string, password []
 			return err
 		}
 
+		if err = revokeSessions(ctx, tx, user.ID); err != nil {
+			return err
+		}
+
+		if err = deleteAccessTokens(ctx, tx, user.ID); err != nil {
+			return err
+		}
+
 		return s.deletePasswordReset(ctx, tx, user.ID)
 	})
 }
 
+func (s *UserStore) CreateMagicLink(ctx context.Context, token string, exp time.Duration, userID int64) error {
+	query := `INSERT INTO magic_links (token, user_id, expiry) VALUES($1, $2, $3)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, token, userID, time.Now().Add(exp))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) ConsumeMagicLink(ctx context.Context, token string) (int64, error) {
+	query := `DELETE FROM magic_links WHERE token = $1 RETURNING user_id, expiry > $2`
+	deleteQuery := `DELETE FROM magic_links WHERE user_id = $1`
+
+	var (
+		userID int64
+		valid  bool
+	)
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		hash := sha256.Sum256([]byte(token))
+		hashToken := hex.EncodeToString(hash[:])
+
+		err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(&userID, &valid)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		// An expired link is committed as deleted rather than rolled back,
+		// so it can't be tried again.
+		if !valid {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, deleteQuery, userID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if !valid {
+		return 0, ErrNotFound
+	}
+
+	return userID, nil
+}
+
+func (s *UserStore) ChangePassword(ctx context.Context, userID, sessionID int64, password []byte) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.updatePassword(ctx, password, userID, tx); err != nil {
+			return err
+		}
+
+		if err := deleteAccessTokens(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return revokeOtherSessions(ctx, tx, userID, sessionID)
+	})
+}
+
+func (s *UserStore) CreateEmailChange(ctx context.Context, token string, exp time.Duration, userID, sessionID int64, email string) error {
+	query := `INSERT INTO email_changes (token, user_id, session_id, email, expiry) VALUES($1, $2, $3, $4, $5)`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.deleteEmailChanges(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, query, token, userID, sessionID, email, time.Now().Add(exp))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, token string) error {
+	query := `
+		SELECT user_id, session_id, email
+		FROM email_changes
+		WHERE token = $1 AND expiry > $2
+	`
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		hash := sha256.Sum256([]byte(token))
+		hashToken := hex.EncodeToString(hash[:])
+
+		var (
+			userID    int64
+			sessionID sql.NullInt64
+			email     string
+		)
+
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err := tx.QueryRowContext(queryCtx, query, hashToken, time.Now()).Scan(&userID, &sessionID, &email)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if err = s.updateEmail(ctx, tx, email, userID); err != nil {
+			return err
+		}
+
+		if err = s.deleteEmailChanges(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		if !sessionID.Valid {
+			return revokeSessions(ctx, tx, userID)
+		}
+
+		return revokeOtherSessions(ctx, tx, userID, sessionID.Int64)
+	})
+}
+
+func (s *UserStore) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	query := `UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_enabled = false`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStore) EnableTOTP(ctx context.Context, userID int64, recoveryCodes []string) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.updateTOTPStatus(ctx, tx, true, userID); err != nil {
+			return err
+		}
+
+		if err := s.deleteRecoveryCodes(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.createRecoveryCodes(ctx, tx, recoveryCodes, userID)
+	})
+}
+
+func (s *UserStore) DisableTOTP(ctx context.Context, userID int64) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.updateTOTPStatus(ctx, tx, false, userID); err != nil {
+			return err
+		}
+
+		return s.deleteRecoveryCodes(ctx, tx, userID)
+	})
+}
+
+func (s *UserStore) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	query := `
+		UPDATE recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code = $3 AND used_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	hash := sha256.Sum256([]byte(code))
+	hashCode := hex.EncodeToString(hash[:])
+
+	res, err := s.db.ExecContext(ctx, query, time.Now(), userID, hashCode)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code. A step at or below
+// the last recorded one was already used, so it is reported as ErrNotFound.
+func (s *UserStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	query := `
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStore) updateTOTPStatus(ctx context.Context, tx *sql.Tx, enabled bool, userID int64) error {
+	query := `UPDATE users SET totp_enabled = $1 WHERE id = $2`
+	if !enabled {
+		query = `UPDATE users SET totp_enabled = $1, totp_secret = NULL, totp_last_step = NULL WHERE id = $2`
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, enabled, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) createRecoveryCodes(ctx context.Context, tx *sql.Tx, codes []string, userID int64) error {
+	query := `INSERT INTO recovery_codes (user_id, code) VALUES ($1, $2)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, query, userID, code); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *UserStore) deleteRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `DELETE FROM recovery_codes WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) GetByIdentity(ctx context.Context, provider, subject string) (*UserDetails, error) {
+	query := `
+		SELECT u.id, u.name, u.username, u.email, u.is_active, u.totp_enabled, u.created_at
+		FROM users u
+		INNER JOIN user_identities ui ON ui.user_id = u.id
+		WHERE ui.provider = $1 AND ui.subject = $2
+	`
+
+	user := &UserDetails{}
+	if err := s.fetchUser(
+		ctx,
+		query,
+		[]any{provider, subject},
+		[]any{&user.ID, &user.Name, &user.Username, &user.Email, &user.IsActive, &user.MFAEnabled, &user.CreatedAt},
+	); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// LinkIdentity links the identity to the user and activates them. When the
+// account isn't active yet, password replaces the one set at registration and
+// every credential issued before the link is dropped, since whoever registered
+// never proved they own the email.
+func (s *UserStore) LinkIdentity(ctx context.Context, userID int64, provider, subject string, password []byte) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if password != nil {
+			if err := s.resetInactiveCredentials(ctx, tx, password, userID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.createIdentity(ctx, tx, userID, provider, subject); err != nil {
+			return err
+		}
+
+		if err := s.updateActivationStatus(ctx, tx, true, userID); err != nil {
+			return err
+		}
+
+		return s.deleteUserInvitation(ctx, tx, userID)
+	})
+}
+
+func (s *UserStore) CreateWithIdentity(ctx context.Context, user *UserDetails, provider, subject string) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.Create(ctx, tx, user); err != nil {
+			return err
+		}
+
+		if err := s.createIdentity(ctx, tx, user.ID, provider, subject); err != nil {
+			return err
+		}
+
+		user.IsActive = true
+		return s.updateActivationStatus(ctx, tx, true, user.ID)
+	})
+}
+
+func (s *UserStore) createIdentity(ctx context.Context, tx *sql.Tx, userID int64, provider, subject string) error {
+	query := `INSERT INTO user_identities (provider, subject, user_id) VALUES ($1, $2, $3)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, provider, subject, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) resetInactiveCredentials(ctx context.Context, tx *sql.Tx, password []byte, userID int64) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2 AND is_active = false`
+	deleteQuery := `DELETE FROM magic_links WHERE user_id = $1`
+
+	queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := tx.ExecContext(queryCtx, query, password, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(queryCtx, deleteQuery, userID); err != nil {
+		return err
+	}
+
+	if err = revokeSessions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err = deleteAccessTokens(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err = s.deletePasswordReset(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return s.deleteEmailChanges(ctx, tx, userID)
+}
+
 func (s *UserStore) getUserFromPasswordReset(ctx context.Context, token string, tx *sql.Tx) (*UserDetails, error) {
 	query := `
 		SELECT u.id, u.name, u.username, email
@@ -305,6 +764,39 @@ func (s *UserStore) deletePasswordReset(ctx context.Context, tx *sql.Tx, userID
 	return nil
 }
 
+func (s *UserStore) updateEmail(ctx context.Context, tx *sql.Tx, email string, userID int64) error {
+	query := `UPDATE users SET email = $1 WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, email, userID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return ErrDuplicateEmail
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *UserStore) deleteEmailChanges(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `DELETE FROM email_changes WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *UserStore) fetchUser(ctx context.Context, query string, args []any, destArgs []any) error {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
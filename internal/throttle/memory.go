@@ -0,0 +1,85 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var maxMemoryEntries = 10_000
+
+type memoryEntry struct {
+	failures     int
+	blockedUntil time.Time
+	updatedAt    time.Time
+}
+
+type MemoryLimiter struct {
+	policy  Policy
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryLimiter(policy Policy) *MemoryLimiter {
+	return &MemoryLimiter{
+		policy:  policy,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (l *MemoryLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return 0, nil
+	}
+
+	return retryAfter(entry.blockedUntil, time.Now()), nil
+}
+
+func (l *MemoryLimiter) Fail(ctx context.Context, key string) (Attempt, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if len(l.entries) >= maxMemoryEntries {
+		l.sweep(now)
+	}
+
+	entry, ok := l.entries[key]
+	if !ok || l.stale(entry, now) {
+		entry = &memoryEntry{}
+		l.entries[key] = entry
+	}
+
+	entry.failures++
+	entry.updatedAt = now
+
+	attempt := l.policy.attempt(entry.failures, now)
+	entry.blockedUntil = attempt.BlockedUntil
+
+	return attempt, nil
+}
+
+func (l *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+	return nil
+}
+
+func (l *MemoryLimiter) stale(entry *memoryEntry, now time.Time) bool {
+	return now.Sub(entry.updatedAt) > l.policy.Window && !entry.blockedUntil.After(now)
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, entry := range l.entries {
+		if l.stale(entry, now) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+var queryTimeoutDuration = time.Second * 5
+
+type PostgresLimiter struct {
+	db     *sql.DB
+	policy Policy
+}
+
+func NewPostgresLimiter(db *sql.DB, policy Policy) *PostgresLimiter {
+	return &PostgresLimiter{
+		db:     db,
+		policy: policy,
+	}
+}
+
+func (l *PostgresLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	query := `SELECT blocked_until FROM auth_attempts WHERE key = $1 AND blocked_until IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutDuration)
+	defer cancel()
+
+	var blockedUntil time.Time
+
+	err := l.db.QueryRowContext(ctx, query, key).Scan(&blockedUntil)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return 0, nil
+		default:
+			return 0, err
+		}
+	}
+
+	return retryAfter(blockedUntil, time.Now()), nil
+}
+
+func (l *PostgresLimiter) Fail(ctx context.Context, key string) (Attempt, error) {
+	upsertQuery := `
+		INSERT INTO auth_attempts (key, failures, updated_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN auth_attempts.updated_at < $3 AND COALESCE(auth_attempts.blocked_until, $2) <= $2 THEN 1
+				ELSE auth_attempts.failures + 1
+			END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING failures
+	`
+	blockQuery := `UPDATE auth_attempts SET blocked_until = $1 WHERE key = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutDuration)
+	defer cancel()
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Attempt{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	var failures int
+	if err = tx.QueryRowContext(ctx, upsertQuery, key, now, now.Add(-l.policy.Window)).Scan(&failures); err != nil {
+		return Attempt{}, err
+	}
+
+	attempt := l.policy.attempt(failures, now)
+
+	var blockedUntil *time.Time
+	if !attempt.BlockedUntil.IsZero() {
+		blockedUntil = &attempt.BlockedUntil
+	}
+
+	if _, err = tx.ExecContext(ctx, blockQuery, blockedUntil, key); err != nil {
+		return Attempt{}, err
+	}
+
+	return attempt, tx.Commit()
+}
+
+func (l *PostgresLimiter) Reset(ctx context.Context, key string) error {
+	query := `DELETE FROM auth_attempts WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutDuration)
+	defer cancel()
+
+	_, err := l.db.ExecContext(ctx, query, key)
+	return err
+}
+
+// Prune deletes attempts that have been idle for longer than olderThan and
+// aren't blocking anything. Limiters share the table, so olderThan should be
+// the widest window among them.
+func (l *PostgresLimiter) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM auth_attempts
+		WHERE updated_at < $1 AND COALESCE(blocked_until, $2) <= $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutDuration)
+	defer cancel()
+
+	now := time.Now()
+
+	res, err := l.db.ExecContext(ctx, query, now.Add(-olderThan), now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
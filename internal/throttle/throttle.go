@@ -0,0 +1,61 @@
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+type Limiter interface {
+	Check(ctx context.Context, key string) (time.Duration, error)
+	Fail(ctx context.Context, key string) (Attempt, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type Attempt struct {
+	Failures     int
+	BlockedUntil time.Time
+	Locked       bool
+	JustLocked   bool
+}
+
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+func (p Policy) attempt(failures int, now time.Time) Attempt {
+	attempt := Attempt{Failures: failures}
+
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		attempt.Locked = true
+		attempt.JustLocked = failures == p.LockoutAfter
+		attempt.BlockedUntil = now.Add(p.LockoutDuration)
+		return attempt
+	}
+
+	if failures <= p.FreeAttempts {
+		return attempt
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	attempt.BlockedUntil = now.Add(delay)
+	return attempt
+}
+
+func retryAfter(blockedUntil, now time.Time) time.Duration {
+	if blockedUntil.After(now) {
+		return blockedUntil.Sub(now)
+	}
+	return 0
+}
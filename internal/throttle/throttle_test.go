@@ -0,0 +1,91 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	LockoutAfter:    6,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+func TestPolicy(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{1, 0, false},
+		{2, 0, false},
+		{3, time.Second, false},
+		{4, 2 * time.Second, false},
+		{5, 4 * time.Second, false},
+		{6, time.Hour, true},
+		{7, time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		attempt := testPolicy.attempt(tt.failures, now)
+
+		if delay := retryAfter(attempt.BlockedUntil, now); delay != tt.delay {
+			t.Errorf("failures %d: expected delay %v, got %v", tt.failures, tt.delay, delay)
+		}
+
+		if attempt.Locked != tt.locked {
+			t.Errorf("failures %d: expected locked %v, got %v", tt.failures, tt.locked, attempt.Locked)
+		}
+
+		if attempt.JustLocked != (tt.failures == testPolicy.LockoutAfter) {
+			t.Errorf("failures %d: unexpected just locked %v", tt.failures, attempt.JustLocked)
+		}
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(testPolicy)
+
+	t.Run("should allow free attempts", func(t *testing.T) {
+		for i := 0; i < testPolicy.FreeAttempts; i++ {
+			if _, err := limiter.Fail(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if retry, _ := limiter.Check(ctx, "a"); retry != 0 {
+			t.Errorf("expected no delay, got %v", retry)
+		}
+	})
+
+	t.Run("should block after free attempts", func(t *testing.T) {
+		if _, err := limiter.Fail(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		if retry, _ := limiter.Check(ctx, "a"); retry <= 0 {
+			t.Errorf("expected delay, got %v", retry)
+		}
+
+		if retry, _ := limiter.Check(ctx, "b"); retry != 0 {
+			t.Errorf("expected other keys to be unaffected, got %v", retry)
+		}
+	})
+
+	t.Run("should clear failures on reset", func(t *testing.T) {
+		if err := limiter.Reset(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		if retry, _ := limiter.Check(ctx, "a"); retry != 0 {
+			t.Errorf("expected no delay after reset, got %v", retry)
+		}
+	})
+}
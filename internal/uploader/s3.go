@@ -38,16 +38,25 @@ type UploadImageOptions struct {
 	Quality int
 	MimeType string
 	Folder string
+	// Fit scales the image down to fit within Width and Height instead of
+	// cropping it to fill them.
+	Fit bool
+	// ID names the uploaded file, a new one is generated when empty. It lets
+	// several sizes of the same image share an ID across folders.
+	ID string
 }
 
 func (u *S3Uploader) ProcessAndUploadImage(ctx context.Context, image image.Image, options UploadImageOptions) (string, string, error) {
-	resizedImage := u.processImage(image, options.Width, options.Height)
+	resizedImage := u.processImage(image, options.Width, options.Height, options.Fit)
 	encodedImage, err := u.encodeImage(resizedImage, options.Quality, options.MimeType)
 	if err != nil {
 		return "", "", err
 	}
 
-	id := uuid.New().String()
+	id := options.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
 	key := fmt.Sprintf("%s/%s", options.Folder, id)
 
 	if err = u.UploadFile(ctx, encodedImage, key, options.MimeType); err != nil {
@@ -83,7 +92,10 @@ func (u *S3Uploader) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
-func (u *S3Uploader) processImage(image image.Image, width, height int) image.Image {
+func (u *S3Uploader) processImage(image image.Image, width, height int, fit bool) image.Image {
+	if fit {
+		return imaging.Fit(image, width, height, imaging.Lanczos)
+	}
 	return imaging.Fill(image, width, height, imaging.Center, imaging.Lanczos)
 }
 
@@ -22,7 +22,7 @@ export type LoginUserPayload = yup.InferType<typeof loginUserPayloadSchema>;
 
 const loginUserApi = async (
   payload: LoginUserPayload
-): Promise<{ token: string }> => {
+): Promise<{ accessToken: string; refreshToken: string }> => {
   const res = await api.post("/v1/auth/login", payload);
   return res.data.data;
 };
@@ -33,8 +33,9 @@ export const useLoginUser = () => {
 
   const { mutate: loginUser, isPending } = useMutation({
     mutationFn: loginUserApi,
-    onSuccess: (token) => {
-      localStorage.setItem("authToken", JSON.stringify(token));
+    onSuccess: ({ accessToken, refreshToken }) => {
+      localStorage.setItem("authToken", JSON.stringify(accessToken));
+      localStorage.setItem("refreshToken", JSON.stringify(refreshToken));
       navigate("/app");
       success("logged in successfully");
     },